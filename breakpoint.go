@@ -1,84 +1,82 @@
 package gdbmi
 
 import (
+	"context"
 	"fmt"
-	"regexp"
-	"strings"
-)
-
-var (
-	breakpoint_info = regexp.MustCompile(`bkpt=\{.*?\}`)
+	"strconv"
 )
 
 // Information about a breakpoint.
 type Breakpoint struct {
-	Number           string
-	Type             BreakpointType
-	Disposition      BreakpointDispositionType
-	Enabled          bool
-	Address          string
-	Function         string
-	Filename         string
-	Fullname         string
-	Line             int
-	At               string
-	Pending          string
-	Thread           string
-	Condition        string
-	Ignore           int
-	Enable           int
-	Mask             string
-	Pass             int
-	OriginalLocation string
-	Times            int
-	Installed        bool
+	Number           string                    `mi:"number"`
+	Type             BreakpointType            `mi:"type"`
+	Disposition      BreakpointDispositionType `mi:"disp"`
+	Enabled          bool                      `mi:"enabled"`
+	Address          string                    `mi:"addr"`
+	Function         string                    `mi:"func"`
+	Filename         string                    `mi:"filename"`
+	Fullname         string                    `mi:"fullname"`
+	Line             int                       `mi:"line"`
+	At               string                    `mi:"at"`
+	Pending          string                    `mi:"pending"`
+	Thread           string                    `mi:"thread"`
+	Condition        string                    `mi:"cond"`
+	Ignore           int                       `mi:"ignore"`
+	Enable           int                       `mi:"enable"`
+	Mask             string                    `mi:"mask"`
+	Pass             int                       `mi:"pass"`
+	OriginalLocation string                    `mi:"original-location"`
+	Times            int                       `mi:"times"`
+	Installed        bool                      `mi:"installed"`
 	// static-tracepoint-marker-string-id
 	// evaluated-by ?
 	// catch-type ?
 }
 
-func parseBreakpointInfo(info string) (*Breakpoint, error) {
+func breakpointInfo(binfo Value) (*Breakpoint, error) {
 	var result Breakpoint
-	binfo := parseStructure(info)
-	result.Number = binfo["number"].(string)
-	t, ok := BreakpointWithName(binfo["type"].(string))
-	if ok {
-		result.Type = t
-	} else {
-		return nil, fmt.Errorf("unknown breakpoint-type: %s", binfo["type"])
-	}
-	d, ok := BreakpointDispositionWithName(binfo["disp"].(string))
-	if ok {
-		result.Disposition = d
-	} else {
-		return nil, fmt.Errorf("unknown breakpoint-disposition-type: %s", binfo["disp"])
-	}
-	result.Enabled = equals("y", mapValueAsString(binfo, "enabled", "n"))
-	result.Address = mapValueAsString(binfo, "addr", "")
-	result.Function = mapValueAsString(binfo, "func", "")
-	result.Filename = mapValueAsString(binfo, "filename", "")
-	result.Fullname = mapValueAsString(binfo, "fullname", "")
-	fmt.Sscanf(mapValueAsString(binfo, "line", "0"), "%d", &result.Line)
-	result.At = mapValueAsString(binfo, "at", "")
-	result.Pending = mapValueAsString(binfo, "pending", "")
-	result.Thread = mapValueAsString(binfo, "thread", "")
-	result.Condition = mapValueAsString(binfo, "cond", "")
-	fmt.Sscanf(mapValueAsString(binfo, "ignore", "0"), "%d", &result.Ignore)
-	fmt.Sscanf(mapValueAsString(binfo, "enable", "0"), "%d", &result.Enable)
-	result.Mask = mapValueAsString(binfo, "mask", "")
-	fmt.Sscanf(mapValueAsString(binfo, "pass", "0"), "%d", &result.Pass)
-	result.OriginalLocation = mapValueAsString(binfo, "original-location", "0")
-	fmt.Sscanf(mapValueAsString(binfo, "times", "0"), "%d", &result.Times)
-	result.Installed = equals("y", mapValueAsString(binfo, "installed", "n"))
+	if err := Unmarshal(binfo, &result); err != nil {
+		return nil, err
+	}
 	return &result, nil
 }
 
+func parseBreakpointInfo(info string) (*Breakpoint, error) {
+	binfo, err := parseStructure(info)
+	if err != nil {
+		return nil, err
+	}
+	return breakpointInfo(binfo)
+}
+
+// breakpointTable returns the breakpoints in the body of a BreakpointTable
+func breakpointTable(res *GDBResult) ([]Breakpoint, error) {
+	var result []Breakpoint
+	body := res.Values.Get("BreakpointTable").Get("body")
+	for i := 0; i < body.Len(); i++ {
+		bp, err := breakpointInfo(body.Index(i).Get("bkpt"))
+		if err != nil {
+			return result, err
+		}
+		result = append(result, *bp)
+	}
+	return result, nil
+}
+
 func (gdb *GDB) Breakpoint(module string, line int) (*Breakpoint, error) {
-	return gdb.Break_insert(fmt.Sprintf("%s:%d", module, line), false, false, false, false, false, nil, nil, nil)
+	return gdb.BreakpointContext(context.Background(), module, line)
+}
+
+func (gdb *GDB) BreakpointContext(ctx context.Context, module string, line int) (*Breakpoint, error) {
+	return gdb.Break_insertContext(ctx, fmt.Sprintf("%s:%d", module, line), false, false, false, false, false, nil, nil, nil)
 }
 
 func (gdb *GDB) Break_insert(location string, istemp bool, ishw bool, createpending bool, disabled bool, tracepoint bool, condition *string, ignorecount *int, threadid *int) (*Breakpoint, error) {
-	c := newCommand("break-insert").add_param(location)
+	return gdb.Break_insertContext(context.Background(), location, istemp, ishw, createpending, disabled, tracepoint, condition, ignorecount, threadid)
+}
+
+func (gdb *GDB) Break_insertContext(ctx context.Context, location string, istemp bool, ishw bool, createpending bool, disabled bool, tracepoint bool, condition *string, ignorecount *int, threadid *int) (*Breakpoint, error) {
+	c := newCommandContext(ctx, "break-insert").add_param(location)
 	c.add_option_when(istemp, "-t")
 	c.add_option_when(ishw, "-h")
 	c.add_option_when(createpending, "-f")
@@ -94,35 +92,50 @@ func (gdb *GDB) Break_insert(location string, istemp bool, ishw bool, createpend
 	if res.Type != Result_done && res.Type != Result_running {
 		return nil, fmt.Errorf("breakpoint insertion was not successful:%s", res.Results)
 	}
-	if strings.HasPrefix(res.Results, "bkpt=") {
-		ln := cutoff(res.Results, "bkpt=", false)
-		return parseBreakpointInfo(ln)
+	if bkpt := res.Values.Get("bkpt"); bkpt.Kind() == Value_tuple {
+		return breakpointInfo(bkpt)
 	}
 	return nil, fmt.Errorf("breakpoint info should start with 'bkpt=', but has value '%s'", res.Results)
 }
 
 func (gdb *GDB) Break_after(number string, count int) (*GDBResult, error) {
-	c := newCommand("break-after").add_param(number).add_param(fmt.Sprintf("%d", count))
+	return gdb.Break_afterContext(context.Background(), number, count)
+}
+
+func (gdb *GDB) Break_afterContext(ctx context.Context, number string, count int) (*GDBResult, error) {
+	c := newCommandContext(ctx, "break-after").add_param(number).add_param(fmt.Sprintf("%d", count))
 	return gdb.send(c)
 }
 
 func (gdb *GDB) Break_commands(number string, cmds ...string) (*GDBResult, error) {
-	c := newCommand("break-commands").add_param(number)
+	return gdb.Break_commandsContext(context.Background(), number, cmds...)
+}
+
+func (gdb *GDB) Break_commandsContext(ctx context.Context, number string, cmds ...string) (*GDBResult, error) {
+	c := newCommandContext(ctx, "break-commands").add_param(number)
 
 	for _, cmd := range cmds {
-		c.add_param(fmt.Sprintf("\"%s\"", cmd))
+		c.add_quoted_param(cmd)
 	}
 	//c.add_param("end")
 	return gdb.send(c)
 }
 
 func (gdb *GDB) Break_condition(number string, cond string) (*GDBResult, error) {
-	c := newCommand("break-condition").add_param(number).add_param(cond)
+	return gdb.Break_conditionContext(context.Background(), number, cond)
+}
+
+func (gdb *GDB) Break_conditionContext(ctx context.Context, number string, cond string) (*GDBResult, error) {
+	c := newCommandContext(ctx, "break-condition").add_param(number).add_param(cond)
 	return gdb.send(c)
 }
 
 func (gdb *GDB) Break_delete(number ...string) (*GDBResult, error) {
-	c := newCommand("break-delete")
+	return gdb.Break_deleteContext(context.Background(), number...)
+}
+
+func (gdb *GDB) Break_deleteContext(ctx context.Context, number ...string) (*GDBResult, error) {
+	c := newCommandContext(ctx, "break-delete")
 	for _, n := range number {
 		c.add_param(n)
 	}
@@ -130,7 +143,11 @@ func (gdb *GDB) Break_delete(number ...string) (*GDBResult, error) {
 }
 
 func (gdb *GDB) Break_disable(number ...string) (*GDBResult, error) {
-	c := newCommand("break-disable")
+	return gdb.Break_disableContext(context.Background(), number...)
+}
+
+func (gdb *GDB) Break_disableContext(ctx context.Context, number ...string) (*GDBResult, error) {
+	c := newCommandContext(ctx, "break-disable")
 	for _, n := range number {
 		c.add_param(n)
 	}
@@ -138,7 +155,11 @@ func (gdb *GDB) Break_disable(number ...string) (*GDBResult, error) {
 }
 
 func (gdb *GDB) Break_enable(number ...string) (*GDBResult, error) {
-	c := newCommand("break-enable")
+	return gdb.Break_enableContext(context.Background(), number...)
+}
+
+func (gdb *GDB) Break_enableContext(ctx context.Context, number ...string) (*GDBResult, error) {
+	c := newCommandContext(ctx, "break-enable")
 	for _, n := range number {
 		c.add_param(n)
 	}
@@ -146,47 +167,54 @@ func (gdb *GDB) Break_enable(number ...string) (*GDBResult, error) {
 }
 
 func (gdb *GDB) Break_info(number string) (*Breakpoint, error) {
-	c := newCommand("break-info")
+	return gdb.Break_infoContext(context.Background(), number)
+}
+
+func (gdb *GDB) Break_infoContext(ctx context.Context, number string) (*Breakpoint, error) {
+	c := newCommandContext(ctx, "break-info")
 	c.add_param(number)
 	r, e := gdb.send(c)
 	if e != nil {
 		return nil, e
 	}
-	breakinfo := breakpoint_info.FindAllString(r.Results, 1)
+	breakinfo, err := breakpointTable(r)
+	if err != nil {
+		return nil, err
+	}
 	if len(breakinfo) > 0 {
-		binfo := breakinfo[0]
-		parseblock := cutoff(binfo, "bkpt=", false)
-		return parseBreakpointInfo(parseblock)
+		return &breakinfo[0], nil
 	}
 	return nil, nil
 }
 
 func (gdb *GDB) Break_list() (*[]Breakpoint, error) {
-	var result []Breakpoint
-	c := newCommand("break-list")
+	return gdb.Break_listContext(context.Background())
+}
+
+func (gdb *GDB) Break_listContext(ctx context.Context) (*[]Breakpoint, error) {
+	c := newCommandContext(ctx, "break-list")
 	r, e := gdb.send(c)
 	if e != nil {
 		return nil, e
 	}
-	breakinfo := breakpoint_info.FindAllString(r.Results, -1)
-	for _, bi := range breakinfo {
-		parseblock := cutoff(bi, "bkpt=", false)
-		bp, err := parseBreakpointInfo(parseblock)
-		if err != nil {
-			return &result, err
-		}
-		result = append(result, *bp)
-	}
-
-	return &result, nil
+	result, err := breakpointTable(r)
+	return &result, err
 }
 
 func (gdb *GDB) Break_passcount(number string, count int) (*GDBResult, error) {
-	c := newCommand("break-passcount").add_param(number).add_param(fmt.Sprintf("%d", count))
+	return gdb.Break_passcountContext(context.Background(), number, count)
+}
+
+func (gdb *GDB) Break_passcountContext(ctx context.Context, number string, count int) (*GDBResult, error) {
+	c := newCommandContext(ctx, "break-passcount").add_param(number).add_param(fmt.Sprintf("%d", count))
 	return gdb.send(c)
 }
 
 func (gdb *GDB) Break_watch(expr string, read bool, write bool) (*GDBResult, error) {
+	return gdb.Break_watchContext(context.Background(), expr, read, write)
+}
+
+func (gdb *GDB) Break_watchContext(ctx context.Context, expr string, read bool, write bool) (*GDBResult, error) {
 	if !(read || write) {
 		return nil, nil
 	}
@@ -196,16 +224,72 @@ func (gdb *GDB) Break_watch(expr string, read bool, write bool) (*GDBResult, err
 	} else if read {
 		option = "-r"
 	}
-	c := newCommand("break-watch").add_option(option)
+	c := newCommandContext(ctx, "break-watch").add_option(option)
 	return gdb.send(c)
 }
 
 func (gdb *GDB) Catch_load(reg string, temp bool, disabled bool) (*GDBResult, error) {
-	c := newCommand("catch-load").add_option_when(temp, "-t").add_option_when(disabled, "-d").add_param(reg)
+	return gdb.Catch_loadContext(context.Background(), reg, temp, disabled)
+}
+
+func (gdb *GDB) Catch_loadContext(ctx context.Context, reg string, temp bool, disabled bool) (*GDBResult, error) {
+	c := newCommandContext(ctx, "catch-load").add_option_when(temp, "-t").add_option_when(disabled, "-d").add_param(reg)
 	return gdb.send(c)
 }
 
 func (gdb *GDB) Catch_unload(reg string, temp bool, disabled bool) (*GDBResult, error) {
-	c := newCommand("catch-unload").add_option_when(temp, "-t").add_option_when(disabled, "-d").add_param(reg)
+	return gdb.Catch_unloadContext(context.Background(), reg, temp, disabled)
+}
+
+func (gdb *GDB) Catch_unloadContext(ctx context.Context, reg string, temp bool, disabled bool) (*GDBResult, error) {
+	c := newCommandContext(ctx, "catch-unload").add_option_when(temp, "-t").add_option_when(disabled, "-d").add_param(reg)
 	return gdb.send(c)
 }
+
+// Catch_fork stops the program when it calls fork and returns the number of
+// the catchpoint. The stopped event has the pid of the child in NewPid.
+func (gdb *GDB) Catch_fork(temp bool) (string, error) {
+	return gdb.Catch_forkContext(context.Background(), temp)
+}
+
+func (gdb *GDB) Catch_forkContext(ctx context.Context, temp bool) (string, error) {
+	return gdb.catch_console(ctx, "fork", temp)
+}
+
+// Catch_vfork stops the program when it calls vfork.
+func (gdb *GDB) Catch_vfork(temp bool) (string, error) {
+	return gdb.Catch_vforkContext(context.Background(), temp)
+}
+
+func (gdb *GDB) Catch_vforkContext(ctx context.Context, temp bool) (string, error) {
+	return gdb.catch_console(ctx, "vfork", temp)
+}
+
+// Catch_exec stops the program when it calls exec. The stopped event has the
+// new executable in NewExec.
+func (gdb *GDB) Catch_exec(temp bool) (string, error) {
+	return gdb.Catch_execContext(context.Background(), temp)
+}
+
+func (gdb *GDB) Catch_execContext(ctx context.Context, temp bool) (string, error) {
+	return gdb.catch_console(ctx, "exec", temp)
+}
+
+// catch_console creates a catchpoint which has no MI command
+func (gdb *GDB) catch_console(ctx context.Context, event string, temp bool) (string, error) {
+	cmd := "catch "
+	if temp {
+		cmd = "tcatch "
+	}
+	res, err := gdb.Console(ctx, cmd+event)
+	if err != nil {
+		return "", err
+	}
+	for _, l := range res.Console {
+		var n int
+		if _, err := fmt.Sscanf(l, "Catchpoint %d", &n); err == nil {
+			return strconv.Itoa(n), nil
+		}
+	}
+	return "", fmt.Errorf("gdbmi: no catchpoint number in %q", res.Console)
+}
@@ -0,0 +1,26 @@
+package gdbmi
+
+import (
+	"testing"
+)
+
+func TestBreakList(t *testing.T) {
+	gdb := NewGDB("unused")
+	table := `BreakpointTable={nr_rows="2",nr_cols="6",hdr=[{width="7",alignment="-1",col_name="number",colhdr="Num"}],body=[bkpt={number="1",type="breakpoint",disp="keep",enabled="y",addr="0x000100d0",func="main",file="hello.c",line="5",times="0"},bkpt={number="2",type="breakpoint",disp="del",enabled="n",addr="0x00010114",func="foo",file="hello.c",line="13",times="1"}]}`
+	vals, err := parseResultList(table)
+	if err != nil {
+		t.Fatalf("cannot parse testdata: %s", err)
+	}
+	gdb.send = createSender(&GDBResult{Type: Result_done, Results: table, Values: vals}, nil)
+	bps, err := gdb.Break_list()
+	if err != nil {
+		t.Fatalf("break list failed: %s", err)
+	}
+	if len(*bps) != 2 {
+		t.Fatalf("break list should contain 2 breakpoints: %+v", bps)
+	}
+	bp := (*bps)[1]
+	if bp.Number != "2" || bp.Disposition != BP_breakpointDisposition_delete || bp.Enabled || bp.Line != 13 || bp.Times != 1 {
+		t.Errorf("wrong breakpoint: %+v", bp)
+	}
+}
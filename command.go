@@ -0,0 +1,38 @@
+package gdbmi
+
+import (
+	"context"
+	"strings"
+)
+
+// An Option of a MI command like "--thread 1" or "-t". An option without a
+// Value is a flag. The leading dash is added if the Name has none.
+type Option struct {
+	Name  string
+	Value string
+}
+
+// Exec sends any GDB/MI command to the debugger and waits for its result. The
+// command is given without the leading dash, e.g.
+//
+//	gdb.Exec(ctx, "data-evaluate-expression", nil, "i + 1")
+//
+// Option values and parameters are quoted if necessary, so they can contain
+// spaces or quotes. The token of the command is assigned automatically. If
+// GDB answers with an error, the error is returned, otherwise the result
+// contains the parsed results and the console output of the command.
+func (gdb *GDB) Exec(ctx context.Context, command string, opts []Option, params ...string) (*GDBResult, error) {
+	c := newCommandContext(ctx, strings.TrimPrefix(command, "-"))
+	c.add_options(opts)
+	for _, p := range params {
+		c.add_quoted_param(p)
+	}
+	return gdb.send(c)
+}
+
+// Console executes a CLI command and returns its result. The output of the
+// command is in the Console field of the result.
+func (gdb *GDB) Console(ctx context.Context, command string) (*GDBResult, error) {
+	c := newCommandContext(ctx, "interpreter-exec").add_param("console")
+	return gdb.send(c.add_quoted_param(command))
+}
@@ -0,0 +1,37 @@
+package gdbmi
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExec(t *testing.T) {
+	tokenGenerator = dummyTokenGenerator
+	gdb := NewGDB("unused")
+	ctx := context.Background()
+	var sent string
+	gdb.send = func(cmd *gdb_command) (*GDBResult, error) {
+		sent = cmd.dump_mi()
+		return &GDBResult{Type: Result_done}, nil
+	}
+	testdata := []struct {
+		f        func()
+		expected string
+	}{
+		{func() { gdb.Exec(ctx, "data-evaluate-expression", nil, "i + 1") }, `1-data-evaluate-expression  "i + 1"`},
+		{func() {
+			gdb.Exec(ctx, "-stack-list-frames", []Option{{"--thread", "1"}, {"no-frame-filters", ""}}, "0", "3")
+		}, `1-stack-list-frames --thread 1 -no-frame-filters 0 3`},
+		{func() { gdb.Exec(ctx, "interpreter-exec", nil, "console", `print "a\b"`) }, `1-interpreter-exec  console "print \"a\\b\""`},
+		{func() { gdb.Exec(ctx, "environment-cd", nil, "") }, `1-environment-cd  ""`},
+		{func() { gdb.Exec(ctx, "data-evaluate-expression", nil, "\n\x01") }, `1-data-evaluate-expression  "\n\001"`},
+		{func() { gdb.Exec_next(true) }, `1-exec-next --reverse `},
+		{func() { gdb.Break_insert("main.go:10", true, false, false, false, false, nil, nil, nil) }, `1-break-insert -t main.go:10`},
+	}
+	for _, td := range testdata {
+		td.f()
+		if sent != td.expected {
+			t.Errorf("wrong command sent: '%s', expected '%s'", sent, td.expected)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package gdbmi
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// An Unmarshaler decodes itself from a MI value.
+type Unmarshaler interface {
+	UnmarshalMI(v Value) error
+}
+
+// An UnmarshalError describes a MI value which cannot be stored in a Go value.
+type UnmarshalError struct {
+	Field string
+	Value string
+	Type  reflect.Type
+	Err   error
+}
+
+func (e *UnmarshalError) Error() string {
+	msg := fmt.Sprintf("gdbmi: cannot unmarshal '%s' into %s of type %s", e.Value, e.Field, e.Type)
+	if e.Err != nil {
+		msg = msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *UnmarshalError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	valueType       = reflect.TypeOf((*Value)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+)
+
+// Unmarshal stores the MI value v in the value pointed to by out. It works like
+// json.Unmarshal: the fields of a struct are taken from a tuple by the name in
+// their mi tag
+//
+//	type Frame struct {
+//		Level   int    `mi:"level"`
+//		Address uint64 `mi:"addr"`
+//	}
+//
+// fields without a tag are ignored, fields missing in the tuple keep their value.
+// Numbers are decimal or hex with a 0x prefix, bools are y/n (or yes/no,
+// true/false, on/off, 1/0). Lists are stored in slices, a const in a slice
+// becomes a slice with one element. The elements of result lists like
+// [frame={...},frame={...}] are unwrapped when the element type has no field
+// with the name of the result. Tuples can also be stored in maps with string
+// keys and any value can be stored in a field of type Value.
+func Unmarshal(v Value, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("gdbmi: Unmarshal needs a non nil pointer, not %T", out)
+	}
+	return unmarshalValue(v, rv.Elem(), "value")
+}
+
+func unmarshalValue(v Value, rv reflect.Value, path string) error {
+	if v == nil || v.Kind() == Value_invalid {
+		return nil
+	}
+	if vt := reflect.TypeOf(v); vt.AssignableTo(rv.Type()) {
+		rv.Set(reflect.ValueOf(v))
+		return nil
+	}
+	if rv.CanAddr() && rv.Addr().Type().Implements(unmarshalerType) {
+		if err := rv.Addr().Interface().(Unmarshaler).UnmarshalMI(v); err != nil {
+			return &UnmarshalError{path, v.String(), rv.Type(), err}
+		}
+		return nil
+	}
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return unmarshalValue(v, rv.Elem(), path)
+	case reflect.Struct:
+		return unmarshalStruct(v, rv, path)
+	case reflect.Slice:
+		return unmarshalSlice(v, rv, path)
+	case reflect.Map:
+		return unmarshalMap(v, rv, path)
+	}
+	c, ok := v.(Const)
+	if !ok {
+		return &UnmarshalError{path, v.String(), rv.Type(), nil}
+	}
+	s := string(c)
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+	case reflect.Bool:
+		b, err := parseBool(s)
+		if err != nil {
+			return &UnmarshalError{path, s, rv.Type(), err}
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(numberText(s))
+		if err == nil && rv.OverflowInt(n) {
+			err = fmt.Errorf("value out of range")
+		}
+		if err != nil {
+			return &UnmarshalError{path, s, rv.Type(), err}
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(numberText(s))
+		if err == nil && rv.OverflowUint(n) {
+			err = fmt.Errorf("value out of range")
+		}
+		if err != nil {
+			return &UnmarshalError{path, s, rv.Type(), err}
+		}
+		rv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, rv.Type().Bits())
+		if err != nil {
+			return &UnmarshalError{path, s, rv.Type(), err}
+		}
+		rv.SetFloat(f)
+	default:
+		return &UnmarshalError{path, s, rv.Type(), nil}
+	}
+	return nil
+}
+
+// numberText returns the digits and the base of a decimal or 0x prefixed hex number
+func numberText(s string) (string, int, int) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return s[2:], 16, 64
+	}
+	if strings.HasPrefix(s, "-0x") || strings.HasPrefix(s, "-0X") {
+		return "-" + s[3:], 16, 64
+	}
+	return s, 10, 64
+}
+
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "y", "yes", "true", "on", "1":
+		return true, nil
+	case "n", "no", "false", "off", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("not a bool")
+}
+
+func miFieldName(f reflect.StructField) string {
+	name := f.Tag.Get("mi")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func unmarshalStruct(v Value, rv reflect.Value, path string) error {
+	if v.Kind() != Value_tuple {
+		return &UnmarshalError{path, v.String(), rv.Type(), nil}
+	}
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := miFieldName(f)
+		if len(name) == 0 || f.PkgPath != "" {
+			continue
+		}
+		if err := unmarshalValue(v.Get(name), rv.Field(i), path+"."+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasMIField checks if t (or the type t points to) is a struct with a field
+// with the given mi name.
+func hasMIField(t reflect.Type, name string) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if miFieldName(t.Field(i)) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func unmarshalSlice(v Value, rv reflect.Value, path string) error {
+	switch v.Kind() {
+	case Value_const:
+		v = List{v}
+	case Value_list:
+	default:
+		return &UnmarshalError{path, v.String(), rv.Type(), nil}
+	}
+	et := rv.Type().Elem()
+	res := reflect.MakeSlice(rv.Type(), v.Len(), v.Len())
+	for i := 0; i < v.Len(); i++ {
+		e := v.Index(i)
+		if t, ok := e.(Tuple); ok && len(t) == 1 && et != valueType && !hasMIField(et, t[0].Name) {
+			e = t[0].Value
+		}
+		if err := unmarshalValue(e, res.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	rv.Set(res)
+	return nil
+}
+
+func unmarshalMap(v Value, rv reflect.Value, path string) error {
+	t, ok := v.(Tuple)
+	if !ok || rv.Type().Key().Kind() != reflect.String {
+		return &UnmarshalError{path, v.String(), rv.Type(), nil}
+	}
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(rv.Type()))
+	}
+	for _, r := range t {
+		e := reflect.New(rv.Type().Elem()).Elem()
+		if err := unmarshalValue(r.Value, e, path+"."+r.Name); err != nil {
+			return err
+		}
+		rv.SetMapIndex(reflect.ValueOf(r.Name).Convert(rv.Type().Key()), e)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package gdbmi
+
+import (
+	"errors"
+	"testing"
+)
+
+type testLocation struct {
+	Number  string `mi:"number"`
+	Address uint64 `mi:"addr"`
+	Enabled bool   `mi:"enabled"`
+}
+
+type testBreakpoint struct {
+	Number    int            `mi:"number"`
+	Offset    int            `mi:"offset"`
+	Enabled   bool           `mi:"enabled"`
+	Groups    []string       `mi:"thread-groups"`
+	Locations []testLocation `mi:"locations"`
+	Frame     *StackFrame    `mi:"frame"`
+	Script    Value          `mi:"script"`
+	Attrs     map[string]int `mi:"attrs"`
+	Ignored   string
+}
+
+func TestUnmarshal(t *testing.T) {
+	vals, err := parseResultList(`number="12",offset="-0x10",enabled="n",thread-groups="i1",locations=[{number="12.1",addr="0x0000000000400d10",enabled="y"},{number="12.2",addr="0x400d20",enabled="n"}],frame={level="1",func="main",line="5"},script=["silent"],attrs={a="1",b="2"},Ignored="x"`)
+	if err != nil {
+		t.Fatalf("cannot parse testdata: %s", err)
+	}
+	var bp testBreakpoint
+	if err := Unmarshal(vals, &bp); err != nil {
+		t.Fatalf("unmarshal failed: %s", err)
+	}
+	if bp.Number != 12 || bp.Offset != -16 || bp.Enabled || len(bp.Groups) != 1 || bp.Groups[0] != "i1" {
+		t.Errorf("wrong simple values: %+v", bp)
+	}
+	if len(bp.Locations) != 2 || bp.Locations[0].Address != 0x400d10 || !bp.Locations[0].Enabled || bp.Locations[1].Number != "12.2" {
+		t.Errorf("wrong locations: %+v", bp.Locations)
+	}
+	if bp.Frame == nil || bp.Frame.Level != 1 || bp.Frame.Function != "main" || bp.Frame.Line != 5 {
+		t.Errorf("wrong frame: %+v", bp.Frame)
+	}
+	if bp.Script.Kind() != Value_list || bp.Script.Index(0).String() != "silent" {
+		t.Errorf("wrong script: %v", bp.Script)
+	}
+	if bp.Attrs["a"] != 1 || bp.Attrs["b"] != 2 || bp.Ignored != "" {
+		t.Errorf("wrong attrs: %+v", bp)
+	}
+
+	var frames []StackFrame
+	stack, _ := parseStructureArray(`[frame={level="0",func="sub"},frame={level="1",func="main"}]`)
+	if err := Unmarshal(stack, &frames); err != nil || len(frames) != 2 || frames[1].Function != "main" {
+		t.Errorf("wrong result list: %+v, %v", frames, err)
+	}
+	var args []FrameArgument
+	names, _ := parseStructureArray(`[name="s1",name="s2"]`)
+	if err := Unmarshal(names, &args); err != nil || len(args) != 2 || args[1].Name != "s2" {
+		t.Errorf("wrong argument names: %+v, %v", args, err)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	broken := []string{
+		`number="12a"`,
+		`enabled="maybe"`,
+		`frame="main"`,
+		`locations={number="1"}`,
+		`locations=[{addr="main"}]`,
+	}
+	for _, b := range broken {
+		vals, err := parseResultList(b)
+		if err != nil {
+			t.Fatalf("cannot parse testdata: %s", err)
+		}
+		var bp testBreakpoint
+		err = Unmarshal(vals, &bp)
+		var uerr *UnmarshalError
+		if !errors.As(err, &uerr) {
+			t.Errorf("'%s' should return an UnmarshalError: %v", b, err)
+		}
+	}
+	var bp Breakpoint
+	vals, _ := parseStructure(`{number="1",type="breakpoint",disp="sometimes"}`)
+	if err := Unmarshal(vals, &bp); err == nil {
+		t.Errorf("unknown disposition should be an error")
+	}
+	if err := Unmarshal(vals, bp); err == nil {
+		t.Errorf("unmarshal into a non pointer should be an error")
+	}
+}
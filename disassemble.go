@@ -0,0 +1,113 @@
+package gdbmi
+
+import (
+	"context"
+	"strconv"
+)
+
+// A DisassembleMode selects if the instructions are listed with their source
+// lines and raw opcodes.
+type DisassembleMode int
+
+const (
+	// only the instructions
+	Disassemble_plain DisassembleMode = 0
+	// instructions grouped by source lines in address order (deprecated by GDB)
+	Disassemble_mixed DisassembleMode = 1
+	// instructions with their raw opcodes
+	Disassemble_opcodes DisassembleMode = 2
+	// Disassemble_mixed with raw opcodes (deprecated by GDB)
+	Disassemble_mixed_opcodes DisassembleMode = 3
+	// instructions grouped by source lines in source order
+	Disassemble_source DisassembleMode = 4
+	// Disassemble_source with raw opcodes
+	Disassemble_source_opcodes DisassembleMode = 5
+)
+
+// An Instruction is a disassembled machine instruction. Offset is the offset
+// of the address in the function, Opcodes are the raw bytes in hex and are
+// only set in the modes with opcodes.
+type Instruction struct {
+	Address  string `json:"address" mi:"address"`
+	Function string `json:"function" mi:"func-name"`
+	Offset   int    `json:"offset" mi:"offset"`
+	Opcodes  string `json:"opcodes" mi:"opcodes"`
+	Text     string `json:"text" mi:"inst"`
+}
+
+// A SourceLine is a line of source code together with its instructions. A line
+// may have no instructions in the source modes.
+type SourceLine struct {
+	Line         int           `json:"line" mi:"line"`
+	File         string        `json:"file" mi:"file"`
+	Fullname     string        `json:"fullname" mi:"fullname"`
+	Instructions []Instruction `json:"instructions" mi:"line_asm_insn"`
+}
+
+// A Disassembly contains all disassembled instructions. In the modes with
+// source lines, the instructions are grouped by Lines too.
+type Disassembly struct {
+	Instructions []Instruction `json:"instructions"`
+	Lines        []SourceLine  `json:"lines"`
+}
+
+// HasSource reports if the mode groups the instructions by source lines.
+func (m DisassembleMode) HasSource() bool {
+	return m == Disassemble_mixed || m == Disassemble_mixed_opcodes || m >= Disassemble_source
+}
+
+// Data_disassemble disassembles the memory selected by the options in the
+// mode. Use RangeOption, FunctionOption or LineOption to select the memory.
+func (gdb *GDB) Data_disassemble(mode DisassembleMode, opts ...Option) (*Disassembly, error) {
+	return gdb.Data_disassembleContext(context.Background(), mode, opts...)
+}
+
+func (gdb *GDB) Data_disassembleContext(ctx context.Context, mode DisassembleMode, opts ...Option) (*Disassembly, error) {
+	c := newCommandContext(ctx, "data-disassemble").add_options(opts)
+	res, err := gdb.send(c.add_param("--").add_param(strconv.Itoa(int(mode))))
+	if err != nil {
+		return nil, err
+	}
+	d := new(Disassembly)
+	insns := res.Values.Get("asm_insns")
+	if !mode.HasSource() {
+		if err := Unmarshal(insns, &d.Instructions); err != nil {
+			return nil, err
+		}
+		return d, nil
+	}
+	if err := Unmarshal(insns, &d.Lines); err != nil {
+		return nil, err
+	}
+	for _, l := range d.Lines {
+		d.Instructions = append(d.Instructions, l.Instructions...)
+	}
+	return d, nil
+}
+
+// RangeOption selects the memory from the start address up to (excluding) the
+// end address. Both are expressions like "$pc" or "$pc + 32".
+func RangeOption(start, end string) []Option {
+	return []Option{{"-s", start}, {"-e", end}}
+}
+
+// FunctionOption selects the whole function which contains the address.
+func FunctionOption(address string) []Option {
+	return []Option{{"-a", address}}
+}
+
+// LineOption selects the given number of lines starting at the line of the
+// file. If lines is negative, the whole function of the line is selected.
+func LineOption(file string, line int, lines int) []Option {
+	opts := []Option{{"-f", file}, {"-l", strconv.Itoa(line)}}
+	if lines >= 0 {
+		opts = append(opts, Option{"-n", strconv.Itoa(lines)})
+	}
+	return opts
+}
+
+// Disassemble disassembles the function which contains the address, e.g. the
+// Address of a StackFrame.
+func (gdb *GDB) Disassemble(ctx context.Context, address string, mode DisassembleMode) (*Disassembly, error) {
+	return gdb.Data_disassembleContext(ctx, mode, FunctionOption(address)...)
+}
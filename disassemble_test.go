@@ -0,0 +1,42 @@
+package gdbmi
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDisassemble(t *testing.T) {
+	gdb := fakeSession(t,
+		expect(`-data-disassemble -s 0x400526 -e "$pc + 8" -- 2`,
+			`1^done,asm_insns=[{address="0x0000000000400526",func-name="main",offset="0",opcodes="55",inst="push   %rbp"},{address="0x0000000000400527",func-name="main",offset="1",opcodes="48 89 e5",inst="mov    %rsp,%rbp"}]`),
+		expect(`-data-disassemble -f main.c -l 5 -- 4`,
+			`1^done,asm_insns=[src_and_asm_line={line="5",file="main.c",fullname="/src/main.c",line_asm_insn=[{address="0x0000000000400526",func-name="main",offset="0",inst="push   %rbp"},{address="0x0000000000400527",func-name="main",offset="1",inst="mov    %rsp,%rbp"}]},src_and_asm_line={line="6",file="main.c",fullname="/src/main.c",line_asm_insn=[]},src_and_asm_line={line="7",file="main.c",fullname="/src/main.c",line_asm_insn=[{address="0x000000000040052a",func-name="main",offset="4",inst="mov    $0x0,%eax"}]}]`),
+		expect(`-data-disassemble -a 0x400526 -- 0`, `1^error,msg="No function contains specified address."`),
+	)
+	d, err := gdb.Data_disassemble(Disassemble_opcodes, RangeOption("0x400526", "$pc + 8")...)
+	if err != nil {
+		t.Fatalf("disassemble failed: %s", err)
+	}
+	if len(d.Lines) != 0 || len(d.Instructions) != 2 {
+		t.Fatalf("wrong disassembly: %+v", d)
+	}
+	if i := d.Instructions[1]; i.Offset != 1 || i.Opcodes != "48 89 e5" || i.Text != "mov    %rsp,%rbp" || i.Function != "main" {
+		t.Errorf("wrong instruction: %+v", i)
+	}
+	d, err = gdb.Data_disassemble(Disassemble_source, LineOption("main.c", 5, -1)...)
+	if err != nil {
+		t.Fatalf("disassemble failed: %s", err)
+	}
+	if len(d.Lines) != 3 || len(d.Instructions) != 3 {
+		t.Fatalf("wrong disassembly: %+v", d)
+	}
+	if l := d.Lines[2]; l.Line != 7 || l.Fullname != "/src/main.c" || len(l.Instructions) != 1 || l.Instructions[0].Address != "0x000000000040052a" {
+		t.Errorf("wrong source line: %+v", l)
+	}
+	if len(d.Lines[1].Instructions) != 0 {
+		t.Errorf("line without instructions expected: %+v", d.Lines[1])
+	}
+	if _, err := gdb.Disassemble(context.Background(), "0x400526", Disassemble_plain); err == nil {
+		t.Errorf("error expected")
+	}
+}
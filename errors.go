@@ -0,0 +1,80 @@
+package gdbmi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	// ErrDebuggerExited is returned by all commands which are sent when GDB
+	// has exited or the debugger was closed.
+	ErrDebuggerExited = errors.New("gdbmi: debugger exited")
+	// ErrCoreTarget is returned by the commands which run or step the program
+	// when a core file is debugged.
+	ErrCoreTarget = errors.New("gdbmi: not available for core targets")
+)
+
+// The only error code GDB defines: the command does not exist.
+const ErrorCode_undefined_command = "undefined-command"
+
+// A GDBError is returned when GDB answers a command with an error result. Message
+// is the (unescaped) message of GDB, Code the optional error code. Command and
+// Token identify the command which failed.
+type GDBError struct {
+	Message string
+	Code    string
+	Command string
+	Token   int64
+}
+
+func (e *GDBError) Error() string {
+	if len(e.Code) > 0 {
+		return fmt.Sprintf("%s (%s)", e.Message, e.Code)
+	}
+	return e.Message
+}
+
+// A TimeoutError is returned when the context of a command is canceled or its
+// deadline is exceeded before GDB answered the command. It wraps the error of
+// the context, so errors.Is(err, context.DeadlineExceeded) can be used too.
+type TimeoutError struct {
+	Command string
+	Token   int64
+	Err     error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("gdbmi: no result for command %d-%s: %s", e.Token, e.Command, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports if the deadline of the command was exceeded (and not
+// canceled)
+func (e *TimeoutError) Timeout() bool {
+	return e.Err == context.DeadlineExceeded
+}
+
+// A PermissionError is returned when GDB may not trace a process, e.g. because
+// of the ptrace_scope of the Yama security module. It matches os.ErrPermission
+// with errors.Is.
+type PermissionError struct {
+	Pid int
+	Err *GDBError
+}
+
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("gdbmi: not permitted to trace process %d: %s", e.Pid, e.Err.Message)
+}
+
+func (e *PermissionError) Unwrap() error {
+	return e.Err
+}
+
+func (e *PermissionError) Is(target error) bool {
+	return target == os.ErrPermission
+}
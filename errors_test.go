@@ -0,0 +1,29 @@
+package gdbmi
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGDBError(t *testing.T) {
+	gdb := fakeSession(t,
+		expect("-data-evaluate-expression x", `1^error,msg="No symbol \"x\" in current context."`),
+		expect("-foo", `1^error,msg="Undefined MI command: foo",code="undefined-command"`),
+	)
+	_, err := gdb.Exec(context.Background(), "data-evaluate-expression", nil, "x")
+	var gerr *GDBError
+	if !errors.As(err, &gerr) {
+		t.Fatalf("error should be a GDBError: %v", err)
+	}
+	if gerr.Message != `No symbol "x" in current context.` || gerr.Code != "" || gerr.Token != 1 || gerr.Command != "-data-evaluate-expression  x" {
+		t.Errorf("wrong error: %+v", gerr)
+	}
+	_, err = gdb.Exec(context.Background(), "foo", nil)
+	if !errors.As(err, &gerr) || gerr.Code != ErrorCode_undefined_command {
+		t.Errorf("error should have an error code: %v", err)
+	}
+	if err.Error() != "Undefined MI command: foo (undefined-command)" {
+		t.Errorf("wrong error text: %s", err)
+	}
+}
@@ -0,0 +1,130 @@
+package gdbmi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// An Evaluation is the value of an expression in a format with its type.
+// NumChildren is the number of fields or elements of structs and arrays,
+// whose Value is only a summary like "{...}".
+type Evaluation struct {
+	Expression  string    `json:"expression"`
+	Value       string    `json:"value"`
+	Type        string    `json:"type"`
+	Format      VarFormat `json:"format"`
+	NumChildren int       `json:"numChildren"`
+}
+
+// An EvalError is returned when an expression cannot be evaluated, e.g. because
+// of a syntax error or an unknown symbol. Message is the message of GDB.
+type EvalError struct {
+	Expression string
+	Message    string
+	Err        error
+}
+
+func (e *EvalError) Error() string {
+	return fmt.Sprintf("gdbmi: cannot evaluate '%s': %s", e.Expression, e.Message)
+}
+
+func (e *EvalError) Unwrap() error {
+	return e.Err
+}
+
+// Evaluate evaluates the expression in the current frame of the current thread
+// or in the frame and thread given with FrameOption and ThreadOption. The
+// value is in the given format, an empty format is natural.
+func (gdb *GDB) Evaluate(ctx context.Context, expression string, format VarFormat, opts ...Option) (*Evaluation, error) {
+	v, err := gdb.Var_createContext(ctx, "", "*", expression, opts...)
+	var gerr *GDBError
+	if errors.As(err, &gerr) {
+		return nil, &EvalError{expression, gerr.Message, gerr}
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer gdb.deleteVariable(ctx, v.Name)
+	if len(format) == 0 {
+		format = Format_natural
+	}
+	ev := &Evaluation{expression, v.Value, v.Type, format, v.NumChildren}
+	if format != Format_natural {
+		if ev.Value, err = gdb.Var_evaluate_expressionContext(ctx, v.Name, format); err != nil {
+			return nil, err
+		}
+	}
+	return ev, nil
+}
+
+func (gdb *GDB) deleteVariable(ctx context.Context, name string) {
+	ctx, cancel := cleanupContext(ctx)
+	defer cancel()
+	gdb.Var_deleteContext(ctx, name, false)
+}
+
+// Data_evaluate_expression returns the value of the expression in the natural
+// format.
+func (gdb *GDB) Data_evaluate_expression(expression string, opts ...Option) (string, error) {
+	return gdb.Data_evaluate_expressionContext(context.Background(), expression, opts...)
+}
+
+func (gdb *GDB) Data_evaluate_expressionContext(ctx context.Context, expression string, opts ...Option) (string, error) {
+	c := newCommandContext(ctx, "data-evaluate-expression").add_options(opts).add_quoted_param(expression)
+	res, err := gdb.send(c)
+	if err != nil {
+		return "", err
+	}
+	return res.Values.Get("value").String(), nil
+}
+
+// Int returns the value as a number. The value may start with its type, like
+// "(char *) 0x4005e4" or "{void (int)} 0x401136 <handler>", and may be
+// followed by a description, like "97 'a'" or "0x4005e4 "hello"", which are
+// skipped.
+func (ev *Evaluation) Int() (int64, error) {
+	f := strings.Fields(skipType(strings.TrimSpace(ev.Value)))
+	if len(f) == 0 {
+		return 0, fmt.Errorf("gdbmi: '%s' has no value", ev.Expression)
+	}
+	s, base, bits := numberText(f[0])
+	switch ev.Format {
+	case Format_octal:
+		base = 8
+	case Format_binary:
+		base = 2
+	}
+	n, err := strconv.ParseInt(s, base, bits)
+	if err != nil {
+		// negative values of unsigned formats
+		u, uerr := strconv.ParseUint(s, base, bits)
+		if uerr != nil {
+			return 0, err
+		}
+		n = int64(u)
+	}
+	return n, nil
+}
+
+// skipType removes a leading "(type)" or "{type}" of a value
+func skipType(s string) string {
+	if len(s) == 0 || (s[0] != '(' && s[0] != '{') {
+		return s
+	}
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(', '{':
+			depth++
+		case ')', '}':
+			depth--
+			if depth == 0 {
+				return s[i+1:]
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package gdbmi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEvaluate(t *testing.T) {
+	defer func(d time.Duration) { cleanupTimeout = d }(cleanupTimeout)
+	cleanupTimeout = 50 * time.Millisecond
+	gdb := fakeSession(t,
+		expect(`-var-create --thread 2 --frame 1 - * "count * 2"`, `1^done,name="var1",numchild="0",value="42",type="int",thread-id="2",has_more="0"`),
+		expect(`-var-evaluate-expression -f hexadecimal var1`, `1^done,value="0x2a"`),
+		expect(`-var-delete var1`, `1^done`),
+		expect(`-var-create - * nosuchvar`, `1^error,msg="-var-create: unable to create variable object"`),
+		expect(`-var-create - * c`, `1^done,name="var2",numchild="0",value="97 'a'",type="char",thread-id="1",has_more="0"`),
+		// the deletion is not answered
+		expect(`-var-delete var2`),
+	)
+	ctx := context.Background()
+	ev, err := gdb.Evaluate(ctx, "count * 2", Format_hexadecimal, ThreadOption("2"), FrameOption(1))
+	if err != nil {
+		t.Fatalf("evaluate failed: %s", err)
+	}
+	if ev.Value != "0x2a" || ev.Type != "int" {
+		t.Errorf("wrong evaluation: %+v", ev)
+	}
+	if n, err := ev.Int(); err != nil || n != 42 {
+		t.Errorf("wrong number: %d, %v", n, err)
+	}
+	_, err = gdb.Evaluate(ctx, "nosuchvar", "")
+	var eerr *EvalError
+	if !errors.As(err, &eerr) || eerr.Expression != "nosuchvar" || eerr.Message != "-var-create: unable to create variable object" {
+		t.Errorf("wrong error: %v", err)
+	}
+	start := time.Now()
+	ev, err = gdb.Evaluate(ctx, "c", "")
+	if err != nil || ev.Value != "97 'a'" {
+		t.Errorf("wrong evaluation: %+v, %v", ev, err)
+	}
+	if d := time.Since(start); d > 10*cleanupTimeout {
+		t.Errorf("deleting the variable should not block: %s", d)
+	}
+}
+
+func TestEvaluationInt(t *testing.T) {
+	for _, tc := range []struct {
+		value  string
+		format VarFormat
+		n      int64
+	}{
+		{"42", Format_natural, 42},
+		{"-1", Format_decimal, -1},
+		{"97 'a'", Format_natural, 97},
+		{"0x4005e4 \"hello\"", Format_natural, 0x4005e4},
+		{"(char *) 0x4005e4 \"hello\"", Format_natural, 0x4005e4},
+		{"{void (int)} 0x401136 <handler>", Format_natural, 0x401136},
+		{"0x2a", Format_hexadecimal, 42},
+		{"0x000000000000002a", Format_zero_hexadecimal, 42},
+		{"0xffffffffffffffff", Format_hexadecimal, -1},
+		{"052", Format_octal, 42},
+		{"1777777777777777777777", Format_octal, -1},
+		{"101010", Format_binary, 42},
+		{"1111111111111111111111111111111111111111111111111111111111111111", Format_binary, -1},
+		{"18446744073709551615", Format_natural, -1},
+	} {
+		ev := &Evaluation{Expression: "x", Value: tc.value, Format: tc.format}
+		if n, err := ev.Int(); err != nil || n != tc.n {
+			t.Errorf("%q in format %s: got %d, %v, expected %d", tc.value, tc.format, n, err, tc.n)
+		}
+	}
+	for _, value := range []string{"", "(int *)", "{...}", "<optimized out>"} {
+		ev := &Evaluation{Expression: "x", Value: value}
+		if n, err := ev.Int(); err == nil {
+			t.Errorf("%q should not be a number: %d", value, n)
+		}
+	}
+}
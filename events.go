@@ -0,0 +1,168 @@
+package gdbmi
+
+import (
+	"sync"
+)
+
+type BackpressurePolicy int
+
+const (
+	// the debugger waits until the subscriber received the event; this stops
+	// the processing of all output of GDB
+	Backpressure_block BackpressurePolicy = iota
+	// new events are dropped when the buffer is full
+	Backpressure_drop_newest
+	// the oldest event in the buffer is dropped when the buffer is full
+	Backpressure_drop_oldest
+)
+
+// An EventFilter selects the events of a subscription. An empty filter selects
+// all events. If Types is not empty, only events of these types are selected.
+// If StopReasons is not empty, stopped events must have one of these reasons;
+// the reasons do not filter other event types.
+type EventFilter struct {
+	Types       []GDBAsyncType
+	StopReasons []GDBStopReason
+}
+
+// A Subscription receives the events of GDB on channel C in the order GDB
+// sent them. C is closed after unsubscribing or when the debugger exits.
+type Subscription struct {
+	C      <-chan GDBEvent
+	c      chan GDBEvent
+	filter EventFilter
+	policy BackpressurePolicy
+
+	mu     sync.Mutex
+	done   chan struct{}
+	closed bool
+}
+
+type eventSubscribers struct {
+	sync.Mutex
+	subscribers map[*Subscription]bool
+	closed      bool
+}
+
+func (f *EventFilter) matches(ev *GDBEvent) bool {
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if t == ev.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.StopReasons) > 0 && ev.Type == Async_stopped {
+		for _, r := range f.StopReasons {
+			if r == ev.StopReason {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Subscribe returns a subscription for the events selected by the filter with
+// a buffer for the given number of events. The policy defines what happens
+// when the buffer is full.
+func (gdb *GDB) Subscribe(filter EventFilter, buffer int, policy BackpressurePolicy) *Subscription {
+	c := make(chan GDBEvent, buffer)
+	s := &Subscription{C: c, c: c, filter: filter, policy: policy, done: make(chan struct{})}
+	gdb.events.Lock()
+	defer gdb.events.Unlock()
+	if gdb.events.closed {
+		s.close()
+		return s
+	}
+	if gdb.events.subscribers == nil {
+		gdb.events.subscribers = make(map[*Subscription]bool)
+	}
+	gdb.events.subscribers[s] = true
+	return s
+}
+
+// Unsubscribe ends the subscription and closes its channel.
+func (gdb *GDB) Unsubscribe(s *Subscription) {
+	gdb.events.Lock()
+	found := gdb.events.subscribers[s]
+	delete(gdb.events.subscribers, s)
+	gdb.events.Unlock()
+	if found {
+		s.close()
+	}
+}
+
+func (s *Subscription) close() {
+	// stop a blocked send before closing the channel
+	close(s.done)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	close(s.c)
+}
+
+func (s *Subscription) send(ev GDBEvent, quit chan bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || !s.filter.matches(&ev) {
+		return
+	}
+	switch s.policy {
+	case Backpressure_block:
+		select {
+		case s.c <- ev:
+		case <-s.done:
+		case <-quit:
+		}
+	case Backpressure_drop_newest:
+		select {
+		case s.c <- ev:
+		default:
+		}
+	case Backpressure_drop_oldest:
+		for {
+			select {
+			case s.c <- ev:
+				return
+			default:
+			}
+			select {
+			case <-s.c:
+			default:
+				// no buffer
+				return
+			}
+		}
+	}
+}
+
+// publish sends the event to all subscribers. It is called by the dispatcher
+// only, so the events are delivered in order.
+func (es *eventSubscribers) publish(ev GDBEvent, quit chan bool) {
+	es.Lock()
+	subscribers := make([]*Subscription, 0, len(es.subscribers))
+	for s := range es.subscribers {
+		subscribers = append(subscribers, s)
+	}
+	es.Unlock()
+	for _, s := range subscribers {
+		s.send(ev, quit)
+	}
+}
+
+func (es *eventSubscribers) close() {
+	es.Lock()
+	subscribers := es.subscribers
+	es.subscribers = nil
+	es.closed = true
+	es.Unlock()
+	for s := range subscribers {
+		s.close()
+	}
+}
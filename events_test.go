@@ -0,0 +1,47 @@
+package gdbmi
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSubscribe(t *testing.T) {
+	gdb := fakeSession(t, expect("-exec-continue",
+		`*running,thread-id="all"`,
+		`*stopped,reason="breakpoint-hit",disp="keep",bkptno="1",thread-id="1",stopped-threads="all"`,
+		`=thread-group-exited,id="i1",exit-code="0"`,
+		`*stopped,reason="exited-normally"`,
+		`1^done`,
+	))
+	all := gdb.Subscribe(EventFilter{}, 10, Backpressure_block)
+	stops := gdb.Subscribe(EventFilter{
+		Types:       []GDBAsyncType{Async_stopped},
+		StopReasons: []GDBStopReason{Async_stopped_breakpoint_hit},
+	}, 10, Backpressure_drop_newest)
+	latest := gdb.Subscribe(EventFilter{}, 1, Backpressure_drop_oldest)
+	other := gdb.Subscribe(EventFilter{}, 1, Backpressure_block)
+	gdb.Unsubscribe(other)
+	// the result comes after the events, so all events are delivered
+	if _, err := gdb.Exec(context.Background(), "exec-continue", nil); err != nil {
+		t.Fatalf("command failed: %s", err)
+	}
+	gdb.Close()
+	expected := []GDBAsyncType{Async_running, Async_stopped, Async_thread_group_exited, Async_stopped}
+	for i, e := range expected {
+		if ev := <-all.C; ev.Type != e {
+			t.Errorf("event %d has type %v, expected %v", i, ev.Type, e)
+		}
+	}
+	if ev := <-stops.C; ev.StopReason != Async_stopped_breakpoint_hit || ev.ThreadId != "1" {
+		t.Errorf("wrong stopped event: %+v", ev)
+	}
+	if ev, ok := <-stops.C; ok {
+		t.Errorf("filtered event received: %+v", ev)
+	}
+	if ev := <-latest.C; ev.StopReason != Async_stopped_exited_normally {
+		t.Errorf("only the latest event should be kept: %+v", ev)
+	}
+	if _, ok := <-other.C; ok {
+		t.Errorf("channel of an unsubscribed subscription should be closed")
+	}
+}
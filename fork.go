@@ -0,0 +1,50 @@
+package gdbmi
+
+import (
+	"context"
+)
+
+type FollowForkMode string
+type FollowExecMode string
+
+const (
+	// after a fork GDB debugs the parent
+	FollowFork_parent FollowForkMode = "parent"
+	// after a fork GDB debugs the child
+	FollowFork_child FollowForkMode = "child"
+
+	// after an exec the new program replaces the program of the inferior
+	FollowExec_same FollowExecMode = "same"
+	// after an exec the new program runs in a new inferior
+	FollowExec_new FollowExecMode = "new"
+)
+
+// Set_follow_fork_mode sets which process GDB debugs after a fork.
+func (gdb *GDB) Set_follow_fork_mode(mode FollowForkMode) (*GDBResult, error) {
+	return gdb.Set_follow_fork_modeContext(context.Background(), mode)
+}
+
+func (gdb *GDB) Set_follow_fork_modeContext(ctx context.Context, mode FollowForkMode) (*GDBResult, error) {
+	return gdb.SetContext(ctx, "follow-fork-mode", string(mode))
+}
+
+// Set_detach_on_fork sets if GDB detaches from the process it does not follow
+// after a fork. Without detaching both processes are debugged as separate
+// inferiors.
+func (gdb *GDB) Set_detach_on_fork(detach bool) (*GDBResult, error) {
+	return gdb.Set_detach_on_forkContext(context.Background(), detach)
+}
+
+func (gdb *GDB) Set_detach_on_forkContext(ctx context.Context, detach bool) (*GDBResult, error) {
+	return gdb.SetContext(ctx, "detach-on-fork", onOff(detach, "on", "off"))
+}
+
+// Set_follow_exec_mode sets if the program of an exec runs in the same or in a
+// new inferior.
+func (gdb *GDB) Set_follow_exec_mode(mode FollowExecMode) (*GDBResult, error) {
+	return gdb.Set_follow_exec_modeContext(context.Background(), mode)
+}
+
+func (gdb *GDB) Set_follow_exec_modeContext(ctx context.Context, mode FollowExecMode) (*GDBResult, error) {
+	return gdb.SetContext(ctx, "follow-exec-mode", string(mode))
+}
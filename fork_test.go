@@ -0,0 +1,50 @@
+package gdbmi
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFollowFork(t *testing.T) {
+	gdb := fakeSession(t,
+		expect("-gdb-set follow-fork-mode child", `1^done`),
+		expect("-gdb-set detach-on-fork off", `1^done`),
+		expect("-gdb-set follow-exec-mode new", `1^done`),
+		expect(`-interpreter-exec console "catch fork"`, `~"Catchpoint 1 (fork)\n"`, `1^done`),
+		expect(`-interpreter-exec console "tcatch exec"`, `~"Catchpoint 2 (exec)\n"`, `1^done`),
+		expect(`-interpreter-exec console "catch vfork"`, `~"Catchpoint 3 (vfork)\n"`, `1^done`),
+		expect("-exec-continue",
+			`1^running`,
+			`*stopped,reason="fork",disp="keep",bkptno="1",newpid="4712",frame={addr="0x1",func="fork",args=[]},thread-id="1",stopped-threads="all"`),
+		expect("-exec-continue",
+			`1^running`,
+			`*stopped,reason="exec",disp="del",bkptno="2",new-exec="/usr/bin/worker",frame={addr="0x2",func="_start",args=[]},thread-id="2",stopped-threads="all"`),
+	)
+	if _, err := gdb.Set_follow_fork_mode(FollowFork_child); err != nil {
+		t.Fatalf("set failed: %s", err)
+	}
+	if _, err := gdb.Set_detach_on_fork(false); err != nil {
+		t.Fatalf("set failed: %s", err)
+	}
+	if _, err := gdb.Set_follow_exec_mode(FollowExec_new); err != nil {
+		t.Fatalf("set failed: %s", err)
+	}
+	if n, err := gdb.Catch_fork(false); err != nil || n != "1" {
+		t.Fatalf("catch fork failed: %s, %s", n, err)
+	}
+	if n, err := gdb.Catch_exec(true); err != nil || n != "2" {
+		t.Fatalf("catch exec failed: %s, %s", n, err)
+	}
+	if n, err := gdb.Catch_vfork(false); err != nil || n != "3" {
+		t.Fatalf("catch vfork failed: %s, %s", n, err)
+	}
+	ctx := context.Background()
+	ev, err := gdb.ContinueAndWait(ctx)
+	if err != nil || ev.StopReason != Async_stopped_fork || ev.NewPid != 4712 {
+		t.Errorf("wrong fork stop: %+v, %v", ev, err)
+	}
+	ev, err = gdb.ContinueAndWait(ctx)
+	if err != nil || ev.StopReason != Async_stopped_exec || ev.NewExec != "/usr/bin/worker" {
+		t.Errorf("wrong exec stop: %+v, %v", ev, err)
+	}
+}
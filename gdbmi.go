@@ -3,106 +3,27 @@ package gdbmi
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/jayschwa/go-pty"
 	"io"
 	_ "log"
 	"os"
 	"os/exec"
-	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
-	"time"
 )
 
-type creator func() gdb_response
-
-type gdb_output struct {
-	*regexp.Regexp
-	creator
-}
-
-func (p gdb_output) Match(s string) bool {
-	return p.MatchString(s)
-}
-
-func (p gdb_output) fields(line string) map[string]string {
-	res := make(map[string]string)
-	names := p.SubexpNames()
-	for i := 1; i < len(names); i++ {
-		res[names[i]] = p.ReplaceAllString(line, fmt.Sprintf("${%s}", names[i]))
-	}
-	return res
-}
-
-func (p gdb_output) Create(ln string) gdb_response {
-	r := p.creator()
-	r.Fill(p.fields(ln))
-	return r
-}
-
 func equals(s1 string, s2 string) bool {
 	return bytes.Equal([]byte(s1), []byte(s2))
 }
 
-func mapValueAsString(m map[string]interface{}, key string, def string) string {
-	v, ok := m[key]
-	if ok {
-		return v.(string)
-	}
-	return def
-}
-
-func cutoff(line string, prefix string, removeQuotes bool) string {
-	start := len(prefix)
-	end := len(line)
-	if removeQuotes {
-		start += 1
-		end -= 1
-	}
-	return string([]byte(line)[start:end])
-}
-
 var (
-	_gdb_delim                        = []byte("(gdb)")
-	tokenGenerator tokenGeneratorType = timetokenGenerator
-	result_record                     = gdb_output{
-		regexp.MustCompile(`^(?P<token>\d*)\^(?P<message>.*)`),
-		func() gdb_response { return new(gdb_result) }}
-	console_output = gdb_output{
-		regexp.MustCompile(`^~(?P<message>.*)`),
-		func() gdb_response { return new(gdb_console_output) }}
-	target_output = gdb_output{
-		regexp.MustCompile(`^@(?P<message>.*)`),
-		func() gdb_response { return new(gdb_target_output) }}
-	log_output = gdb_output{
-		regexp.MustCompile(`^\&(?P<message>.*)`),
-		func() gdb_response { return new(gdb_log_output) }}
-	notify_async_output = gdb_output{
-		regexp.MustCompile(`^(?P<token>\d*)=(?P<message>.*)`),
-		func() gdb_response { return new(gdb_async) }}
-	exec_async_output = gdb_output{
-		regexp.MustCompile(`^(?P<token>\d*)\*(?P<message>.*)`),
-		func() gdb_response { return new(gdb_async) }}
-	status_async_output = gdb_output{
-		regexp.MustCompile(`^(?P<token>\d*)\+(?P<message>.*)`),
-		func() gdb_response { return new(gdb_async) }}
-	gdb_delim = gdb_output{
-		regexp.MustCompile(`\(gdb\)`),
-		func() gdb_response { return new(gdb_ready) }}
-
-	gdb_responses = []gdb_output{
-		result_record,
-		console_output,
-		target_output,
-		log_output,
-		notify_async_output,
-		exec_async_output,
-		status_async_output,
-	}
-
-	async_running_line = regexp.MustCompile("running,thread-id=\"(.*)\"")
+	tokenGenerator tokenGeneratorType = counterTokenGenerator
+	tokenCounter   int64
 )
 
 type tokenGeneratorType func() int64
@@ -113,34 +34,47 @@ type gdb_command struct {
 	parameter []string
 	options   []string
 	result    chan gdb_response
+	ctx       context.Context
 }
 
 type gdb_response interface {
 	Token() int64
 	Line() string
-	Fill(fields map[string]string) error
 }
 type gdb_response_type struct {
 	token int64
 	line  string
 }
-type gdb_ready struct {
-	gdb_response_type
-}
+
+// a result record (^done,...); err is set when the record could not be parsed
 type gdb_result struct {
 	gdb_response_type
+	class   string
+	results Tuple
+	raw     string
+	err     error
+	console []string
+	log     []string
 }
+
+// an exec (*), status (+) or notify (=) async record
 type gdb_async struct {
 	gdb_response_type
+	kind    byte
+	class   string
+	results Tuple
 }
 type gdb_console_output struct {
 	gdb_response_type
+	text string
 }
 type gdb_log_output struct {
 	gdb_response_type
+	text string
 }
 type gdb_target_output struct {
 	gdb_response_type
+	text string
 }
 
 func (r *gdb_response_type) Token() int64 {
@@ -149,37 +83,41 @@ func (r *gdb_response_type) Token() int64 {
 func (r *gdb_response_type) Line() string {
 	return r.line
 }
-func (r *gdb_response_type) Fill(fields map[string]string) error {
-	token, ok := fields["token"]
-	if ok && len(token) > 0 {
-		tok, perr := strconv.ParseInt(token, 10, 64)
-		if perr != nil {
-			return fmt.Errorf("cannot parse token %s", token)
-		} else {
-			r.token = tok
-		}
-	}
-	r.line = fields["message"]
-	return nil
-}
 
-func timetokenGenerator() int64 {
-	return time.Now().UnixNano()
+func counterTokenGenerator() int64 {
+	return atomic.AddInt64(&tokenCounter, 1)
 }
 
 func newCommand(cmd string) *gdb_command {
 	c := new(gdb_command)
 	c.token = tokenGenerator()
 	c.cmd = cmd
-	c.result = make(chan gdb_response)
+	c.result = make(chan gdb_response, 1)
+
+	return c
+}
 
+func newCommandContext(ctx context.Context, cmd string) *gdb_command {
+	c := newCommand(cmd)
+	c.ctx = ctx
 	return c
 }
 
+func (c *gdb_command) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
 func (c *gdb_command) add_param(p string) *gdb_command {
 	c.parameter = append(c.parameter, p)
 	return c
 }
+func (c *gdb_command) add_quoted_param(p string) *gdb_command {
+	c.parameter = append(c.parameter, quote_param(p))
+	return c
+}
 func (c *gdb_command) add_existing(p *string) *gdb_command {
 	if p != nil {
 		c.parameter = append(c.parameter, *p)
@@ -195,19 +133,23 @@ func (c *gdb_command) add_existing_int(p *int) *gdb_command {
 
 func (c *gdb_command) add_option_stringvalue(opt string, optparam *string) *gdb_command {
 	if optparam != nil {
-		c.options = append(c.options, fmt.Sprintf("-%s %s", opt, *optparam))
+		c.options = append(c.options, fmt.Sprintf("%s %s", option_name(opt), *optparam))
 	}
 	return c
 }
 func (c *gdb_command) add_option_intvalue(opt string, optparam *int) *gdb_command {
 	if optparam != nil {
-		c.options = append(c.options, fmt.Sprintf("-%s %d", opt, *optparam))
+		c.options = append(c.options, fmt.Sprintf("%s %d", option_name(opt), *optparam))
 	}
 	return c
 }
+func (c *gdb_command) add_option_value(opt string, value string) *gdb_command {
+	c.options = append(c.options, fmt.Sprintf("%s %s", option_name(opt), quote_param(value)))
+	return c
+}
 
 func (c *gdb_command) add_option(opt string) *gdb_command {
-	c.options = append(c.options, fmt.Sprintf("-%s", opt))
+	c.options = append(c.options, option_name(opt))
 	return c
 }
 func (c *gdb_command) add_option_when(flg bool, opt string) *gdb_command {
@@ -216,12 +158,69 @@ func (c *gdb_command) add_option_when(flg bool, opt string) *gdb_command {
 	}
 	return c
 }
+func (c *gdb_command) add_options(opts []Option) *gdb_command {
+	for _, o := range opts {
+		if len(o.Value) == 0 {
+			c.add_option(o.Name)
+		} else {
+			c.add_option_value(o.Name, o.Value)
+		}
+	}
+	return c
+}
 
-func (c *gdb_command) dump_mi() string {
+// option_name adds the leading dash if the option has none.
+func option_name(opt string) string {
+	if strings.HasPrefix(opt, "-") {
+		return opt
+	}
+	return "-" + opt
+}
+
+// quote_param returns p as a C string if GDB would otherwise split it or
+// interpret its characters.
+func quote_param(p string) string {
+	needsQuotes := strings.IndexFunc(p, func(r rune) bool {
+		return r <= ' ' || r == '"' || r == '\\' || r == 0x7f
+	})
+	if len(p) > 0 && needsQuotes < 0 {
+		return p
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+	for i := 0; i < len(p); i++ {
+		switch ch := p[i]; ch {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(ch)
+		case '\n':
+			buf.WriteString("\\n")
+		case '\t':
+			buf.WriteString("\\t")
+		case '\r':
+			buf.WriteString("\\r")
+		default:
+			if ch < 0x20 || ch == 0x7f {
+				fmt.Fprintf(&buf, "\\%03o", ch)
+			} else {
+				buf.WriteByte(ch)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+// mi_command returns the command with its options and parameters but without token
+func (c *gdb_command) mi_command() string {
 	p := strings.Join(c.parameter, " ")
 	o := strings.Join(c.options, " ")
 
-	return fmt.Sprintf("%d-%s %s %s", c.token, c.cmd, o, p)
+	return fmt.Sprintf("-%s %s %s", c.cmd, o, p)
+}
+
+func (c *gdb_command) dump_mi() string {
+	return fmt.Sprintf("%d%s", c.token, c.mi_command())
 }
 
 type GDBResultType int
@@ -234,10 +233,17 @@ const (
 	Result_exit
 )
 
+// The result of a command. Results contains the unparsed text of the results,
+// Values the parsed results and Console the console output GDB printed while
+// executing the command, Log its log output. ErrorMessage is the message of an
+// error result.
 type GDBResult struct {
 	Type         GDBResultType `json:"type"`
 	Results      string        `json:"results"`
+	Values       Tuple         `json:"values"`
 	ErrorMessage string        `json:"errorMessage"`
+	Console      []string      `json:"console"`
+	Log          []string      `json:"log"`
 }
 
 type GDBAsyncType int
@@ -293,6 +299,14 @@ const (
 	Async_stopped_vfork
 	Async_stopped_syscall_entry
 	Async_stopped_exec
+	// GDB reports no reason, e.g. when attaching to a process
+	Async_stopped_no_reason
+	// the replay of a recording reached its beginning or end
+	Async_stopped_no_history
+	Async_stopped_syscall_return
+	// a reason which is not known, e.g. of a newer GDB; the reason of GDB
+	// is in the StopReasonText of the event
+	Async_stopped_unknown
 )
 
 type stopReasons struct {
@@ -460,7 +474,22 @@ func init() {
 	allStopReasons.add("fork", Async_stopped_fork)
 	allStopReasons.add("vfork", Async_stopped_vfork)
 	allStopReasons.add("syscall-entry", Async_stopped_syscall_entry)
+	allStopReasons.add("syscall-return", Async_stopped_syscall_return)
 	allStopReasons.add("exec", Async_stopped_exec)
+	allStopReasons.add("no-history", Async_stopped_no_history)
+	// not sent by GDB, which omits the reason
+	allStopReasons.add("no-reason", Async_stopped_no_reason)
+	allStopReasons.add("unknown", Async_stopped_unknown)
+}
+
+// exitCode returns the exit-code of the values. GDB reports exit codes in
+// octal with a leading 0.
+func exitCode(v Value) (int, bool) {
+	code, err := strconv.ParseInt(v.Get("exit-code").String(), 0, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int(code), true
 }
 
 func (rt GDBResultType) String() string {
@@ -483,12 +512,31 @@ func (bp BreakpointDispositionType) String() string {
 	return allBreakpointDispositionTypes.breakId2Name[bp]
 }
 
+func (bp *BreakpointType) UnmarshalMI(v Value) error {
+	t, ok := BreakpointWithName(v.String())
+	if !ok {
+		return fmt.Errorf("unknown breakpoint-type: %s", v)
+	}
+	*bp = t
+	return nil
+}
+
+func (bp *BreakpointDispositionType) UnmarshalMI(v Value) error {
+	d, ok := BreakpointDispositionWithName(v.String())
+	if !ok {
+		return fmt.Errorf("unknown breakpoint-disposition-type: %s", v)
+	}
+	*bp = d
+	return nil
+}
+
 // This event happens async in GDB. Not all fields are filled, but the Type is never empty. Depending on
 // the Type the other fields are filled or not. Look at the GDB/MI documentation to find more information
-// about the fields.
+// about the fields. GDB does not report the Siginfo of a signal, it can be read with (*GDB).StopSiginfo.
 type GDBEvent struct {
 	Type                  GDBAsyncType     `json:"type"`
 	StopReason            GDBStopReason    `json:"stopReason"`
+	StopReasonText        string           `json:"stopReasonText"`
 	ThreadId              string           `json:"threadId"`
 	ThreadGroupid         string           `json:"threadGroupId"`
 	StoppedThreads        []string         `json:"stoppendThreads"`
@@ -502,7 +550,7 @@ type GDBEvent struct {
 	TsvInitial            string           `json:"tsvInitial"`
 	CmdParam              string           `json:"cmdParam"`
 	CmdValue              string           `json:"cmdValue"`
-	MemoryAddress         int              `json:"memoryAddress"`
+	MemoryAddress         uint64           `json:"memoryAddress"`
 	MemoryLen             int              `json:"memoryLen"`
 	MemoryTypeCode        bool             `json:"memoryTypeCode"`
 	BreakpointNumber      string           `json:"breakpointNumber"`
@@ -510,36 +558,49 @@ type GDBEvent struct {
 	CurrentStackArguments *[]FrameArgument `json:"currentStackArguments"`
 	SignalName            string           `json:"signalName"`
 	SignalMeaning         string           `json:"signalMeaning"`
+	NewPid                int              `json:"newPid"`
+	NewExec               string           `json:"newExec"`
+	RecordMethod          string           `json:"recordMethod"`
+	RecordFormat          string           `json:"recordFormat"`
+	Values                Tuple            `json:"values"`
 }
 
 // A running debugger
 type GDB struct {
-	Event chan GDBEvent
-	//Target           chan GDBTargetConsoleEvent
-	DebuggerProcess  *os.Process
-	TargetConsoleOut io.Reader
-	TargetConsoleIn  io.Writer
-	Running          bool
+	DebuggerProcess *os.Process
+	// TargetConsoleIn is the input of the terminal of the debugged program,
+	// its output is returned by InferiorOutput
+	TargetConsoleIn io.Writer
 
 	quit     chan bool
+	quitOnce sync.Once
+	done     chan struct{}
 	stdout   io.ReadCloser
 	stderr   io.ReadCloser
 	stdin    io.WriteCloser
 	commands chan gdb_command
+	streams  streamSubscribers
+	events   eventSubscribers
+	threads  threadStates
+	records  recordStates
+	cancel   chan int64
 	result   chan gdb_response
 	send     func(cmd *gdb_command) (*GDBResult, error)
 	start    func(gdb *GDB, gdbpath string, gdbparms []string, env []string) error
 	gdbpath  string
+	cmd      *exec.Cmd
 	tty      *os.File
+	inferior inferiorIO
+	core     int32
 }
 
 func NewGDB(gdbpath string) *GDB {
 	gdb := new(GDB)
-	gdb.Event = make(chan GDBEvent)
-	//gdb.Target = make(chan GDBTargetConsoleEvent)
-
 	gdb.quit = make(chan bool)
+	gdb.done = make(chan struct{})
 	gdb.commands = make(chan gdb_command)
+	gdb.cancel = make(chan int64)
+	gdb.inferior.start = make(chan bool, 1)
 	gdb.result = make(chan gdb_response)
 	gdb.send = gdb.gdbsend
 	gdb.start = startupGDB
@@ -547,9 +608,14 @@ func NewGDB(gdbpath string) *GDB {
 
 	return gdb
 }
+
+// Start starts GDB with the given executable. Without an executable GDB is
+// started without a program, e.g. to attach to a running process.
 func (gdb *GDB) Start(executable string, env ...string) error {
 	gdbargs := []string{"-q", "--nx", "--nw", "-i", "mi2"}
-	gdbargs = append(gdbargs, executable)
+	if len(executable) > 0 {
+		gdbargs = append(gdbargs, executable)
+	}
 
 	if err := gdb.start(gdb, gdb.gdbpath, gdbargs, env); err != nil {
 		return err
@@ -557,17 +623,23 @@ func (gdb *GDB) Start(executable string, env ...string) error {
 	return nil
 }
 
+// Close stops the processing of the debugger output. Commands which wait for
+// their result fail with ErrDebuggerExited. Closing the input of GDB lets
+// the debugger terminate.
 func (gdb *GDB) Close() {
-	if gdb.tty != nil {
-		gdb.tty.Close()
-	}
-	close(gdb.quit)
+	gdb.quitOnce.Do(func() {
+		if gdb.tty != nil {
+			gdb.tty.Close()
+		}
+		if gdb.stdin != nil {
+			gdb.stdin.Close()
+		}
+		close(gdb.quit)
+	})
 
 	/*
-		gdb.stdin.Close()
 		gdb.stdout.Close()
 		gdb.stderr.Close()
-		close(gdb.Event)
 		close(gdb.Target) */
 }
 
@@ -576,6 +648,7 @@ func startupGDB(gdb *GDB, gdbpath string, gdbargs []string, env []string) error
 	cmd.Env = env
 
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	gdb.cmd = cmd
 
 	pipe, err := cmd.StdoutPipe()
 
@@ -600,70 +673,99 @@ func startupGDB(gdb *GDB, gdbpath string, gdbargs []string, env []string) error
 	}
 	gdb.DebuggerProcess = cmd.Process
 	targetpty, ptyname, err := pty.Open()
+	if err == nil {
+		targetpty, err = pollableTerminal(targetpty)
+	}
 	if err == nil {
 		gdb.tty = targetpty
-		go func() {
-			gdb.Inferior_tty_set(ptyname)
-			//gdb.parse_target_output()
-
-			gdb.TargetConsoleOut = targetpty
-			gdb.TargetConsoleOut = targetpty
-		}()
 	}
-	go func() {
-		open_commands := make(map[int64]*gdb_command)
-		for {
-			select {
-			case <-gdb.quit:
-				close(gdb.commands)
-				//close(gdb.Target)
-				close(gdb.Event)
+	go gdb.dispatch()
+	if err != nil {
+		gdb.Close()
+		return err
+	}
+	if _, err := gdb.Inferior_tty_set(ptyname); err != nil {
+		gdb.Close()
+		return err
+	}
+	gdb.TargetConsoleIn = targetpty
+	go gdb.pumpInferiorOutput(targetpty)
+	return nil
+}
+
+// dispatch sends the commands to GDB and the results back to the waiting
+// commands. When GDB exits or the debugger is closed, all waiting commands
+// fail.
+func (gdb *GDB) dispatch() {
+	open_commands := make(map[int64]*gdb_command)
+	defer func() {
+		for _, c := range open_commands {
+			res := new(gdb_result)
+			res.token = c.token
+			res.err = ErrDebuggerExited
+			c.result <- res
+		}
+		gdb.streams.close()
+		gdb.events.close()
+		close(gdb.done)
+	}()
+	// GDB executes the commands in order, so the stream output up to a
+	// result record belongs to the command of this result
+	var console, log []string
+	for {
+		select {
+		case <-gdb.quit:
+			return
+		case c := <-gdb.commands:
+			gdb.send_to_gdb(&c)
+			open_commands[c.token] = &c
+		case token := <-gdb.cancel:
+			delete(open_commands, token)
+		case r, ok := <-gdb.result:
+			if !ok {
 				return
-			case c, ok := <-gdb.commands:
-				if !ok {
-					return
-				}
-				gdb.send_to_gdb(&c)
-				open_commands[c.token] = &c
-			case r, ok := <-gdb.result:
-				if !ok {
-					return
+			}
+			switch rt := r.(type) {
+			case *gdb_result:
+				rt.console = console
+				rt.log = log
+				console, log = nil, nil
+				waiting_cmd, ok := open_commands[r.Token()]
+				if ok {
+					delete(open_commands, r.Token())
+					waiting_cmd.result <- r
 				}
-				switch rt := r.(type) {
-				case *gdb_result:
-					waiting_cmd, ok := open_commands[r.Token()]
-					if ok {
-						waiting_cmd.result <- r
-					}
-				case *gdb_console_output:
-				case *gdb_log_output:
-					break
-				case *gdb_async:
-					ev, err := createAsync(gdb, rt)
-					if err != nil {
-					} else {
-						go func() {
-							gdb.Event <- *ev
-						}()
-					}
+			case *gdb_console_output:
+				console = append(console, rt.text)
+				gdb.streams.publish(StreamRecord{Stream_console, rt.text})
+			case *gdb_log_output:
+				log = append(log, rt.text)
+				gdb.streams.publish(StreamRecord{Stream_log, rt.text})
+			case *gdb_target_output:
+				gdb.streams.publish(StreamRecord{Stream_target, rt.text})
+			case *gdb_async:
+				gdb.inferiorState(rt)
+				gdb.threads.update(rt)
+				gdb.records.update(rt)
+				ev, err := createAsync(gdb, rt)
+				if err == nil {
+					gdb.events.publish(*ev, gdb.quit)
 				}
 			}
 		}
-	}()
-	return nil
+	}
 }
 
-func (gdb *GDB) parse_target_output() {
-	buf := bufio.NewReader(gdb.tty)
-	for {
-		var ln []byte
-		ln, err := buf.ReadBytes('\n')
-		if err != nil {
-			return
+// inferiorState tells the pump of the terminal output when the program starts
+// and exits
+func (gdb *GDB) inferiorState(rt *gdb_async) {
+	switch rt.class {
+	case "thread-group-started":
+		gdb.inferior.started(rt.results.Get("id").String())
+	case "thread-group-exited":
+		if gdb.tty != nil {
+			gdb.inferior.exited(gdb.tty, rt.results.Get("id").String())
 		}
-		rsp := new(gdb_target_output)
-		rsp.line = string(ln)
-		gdb.result <- rsp
 	}
 }
 
@@ -674,29 +776,45 @@ func (gdb *GDB) parse_gdb_output() {
 		ln, err := buf.ReadBytes('\n')
 		if err != nil {
 			close(gdb.result)
+			if gdb.cmd != nil {
+				gdb.cmd.Wait()
+			}
 			return
 		}
 		ln = bytes.TrimSpace(ln)
-		sline := string(ln)
-		if gdb_delim.Match(sline) {
+		if len(ln) == 0 {
 			continue
-		} else {
-			found := false
-			for _, rt := range gdb_responses {
-				if rt.Match(sline) {
-					found = true
-					rsp := rt.Create(sline)
-					gdb.result <- rsp
-				}
-			}
-			if !found {
-				rsp := new(gdb_target_output)
-				rsp.line = sline
-				gdb.result <- rsp
+		}
+		if rsp := parseOutputLine(string(ln)); rsp != nil {
+			select {
+			case gdb.result <- rsp:
+			case <-gdb.done:
 			}
 		}
+	}
+}
 
+// parseOutputLine never fails: a broken result record is delivered with its
+// parse error so the waiting command does not hang, everything else which is
+// not valid MI output is treated as output of the target.
+func parseOutputLine(line string) gdb_response {
+	rsp, err := parseRecord(line)
+	if err == nil {
+		return rsp
 	}
+	p := &miParser{input: line}
+	token, terr := p.parseToken()
+	if terr == nil && !p.eof() && p.input[p.pos] == '^' {
+		res := new(gdb_result)
+		res.token = token
+		res.line = line[p.pos+1:]
+		res.err = err
+		return res
+	}
+	out := new(gdb_target_output)
+	out.line = line
+	out.text = line
+	return out
 }
 
 func (gdb *GDB) send_to_gdb(cmd *gdb_command) {
@@ -704,31 +822,47 @@ func (gdb *GDB) send_to_gdb(cmd *gdb_command) {
 }
 
 func (gdb *GDB) gdbsend(cmd *gdb_command) (*GDBResult, error) {
-	gdb.commands <- *cmd
-	rsp := <-cmd.result
-	result, err := createResult(rsp.(*gdb_result))
+	if gdb.isCore() && strings.HasPrefix(cmd.cmd, "exec-") && cmd.cmd != "exec-arguments" {
+		return nil, ErrCoreTarget
+	}
+	ctx := cmd.context()
+	select {
+	case gdb.commands <- *cmd:
+	case <-ctx.Done():
+		return nil, &TimeoutError{cmd.cmd, cmd.token, ctx.Err()}
+	case <-gdb.done:
+		return nil, ErrDebuggerExited
+	}
+	var rsp gdb_response
+	select {
+	case rsp = <-cmd.result:
+	case <-ctx.Done():
+		// nobody waits for the result any more
+		select {
+		case gdb.cancel <- cmd.token:
+		case <-gdb.done:
+		}
+		return nil, &TimeoutError{cmd.cmd, cmd.token, ctx.Err()}
+	}
+	res, ok := rsp.(*gdb_result)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response for command %s: %s", cmd.cmd, rsp.Line())
+	}
+	result, err := createResult(res)
 	if err == nil {
 		if result.Type == Result_error {
-			return nil, fmt.Errorf("%s", result.ErrorMessage)
+			return nil, &GDBError{
+				Message: result.ErrorMessage,
+				Code:    result.Values.Get("code").String(),
+				Command: cmd.mi_command(),
+				Token:   cmd.token,
+			}
 		}
 		return result, nil
 	}
 	return nil, err
 }
 
-func splitKVList(kvlist string) map[string]string {
-	res := make(map[string]string)
-	parts := strings.Split(kvlist, ",")
-	for _, p := range parts {
-		kv := strings.Split(p, "=")
-		if len(kv) > 1 {
-			val := string([]byte(kv[1])[1 : len(kv[1])-1])
-			res[kv[0]] = val
-		}
-	}
-	return res
-}
-
 func asyncTypeFromString(tp string) GDBAsyncType {
 	t, ok := AsyncTypeWithName(tp)
 	if !ok {
@@ -739,73 +873,80 @@ func asyncTypeFromString(tp string) GDBAsyncType {
 
 func createAsync(gdb *GDB, res *gdb_async) (*GDBEvent, error) {
 	var result GDBEvent
-	toks := strings.SplitN(res.Line(), ",", 2)
-	result.Type = asyncTypeFromString(toks[0])
-	sub := []byte(res.Line())[len(result.Type.String())+1:]
-	params := splitKVList(string(sub))
+	result.Type = asyncTypeFromString(res.class)
+	result.Values = res.results
+	params := res.results
 	switch result.Type {
 	case Async_running:
-		gdb.Running = true
-		result.ThreadId = async_running_line.ReplaceAllString(res.Line(), "$1")
+		result.ThreadId = params.Get("thread-id").String()
 		return &result, nil
 	case Async_stopped:
-		gdb.Running = false
-		strct := parseStructure(fmt.Sprintf("{%s}", sub))
-		result.ThreadId = strct.get_string("thread-id", "")
-		result.StoppedThreads = strct.get_string_array("stopped-threads")
-		result.StopCore = strct.get_string("core", "")
-		result.SignalName = strct.get_string("signal-name", "")
-		result.SignalMeaning = strct.get_string("signal-meaning", "")
-		frame, ok := strct["frame"]
-		if ok {
-			sinfo, err := stackFrameInfo(frame.(gdbStruct))
+		result.ThreadId = params.Get("thread-id").String()
+		result.StoppedThreads = valueStrings(params.Get("stopped-threads"))
+		result.StopCore = params.Get("core").String()
+		result.SignalName = params.Get("signal-name").String()
+		result.SignalMeaning = params.Get("signal-meaning").String()
+		// fork and vfork catchpoints report the child, exec the new program
+		if pid, err := params.Get("newpid").Int(); err == nil {
+			result.NewPid = pid
+		}
+		result.NewExec = params.Get("new-exec").String()
+		if code, ok := exitCode(params); ok {
+			result.ExitCode = code
+		}
+		frame := params.Get("frame")
+		if frame.Kind() == Value_tuple {
+			sinfo, err := stackFrameInfo(frame)
 			if err == nil {
 				result.CurrentStackFrame = sinfo
-				faargs := frame.(gdbStruct)["args"]
-				fa := frameArguments(faargs.([]interface{}))
-				result.CurrentStackArguments = &fa
+				result.CurrentStackArguments, _ = frameArguments(frame.Get("args"))
 			} else {
 				//log.Printf("Error getting stackframeinfo: %v", err)
 			}
 		}
-		reason := strct.get_string("reason", "")
+		reason := params.Get("reason").String()
 		sr, ok := StopReasonWithName(reason)
-		if !ok {
-			return nil, fmt.Errorf("Error: unknown stopreaseon: %s", reason)
+		result.StopReasonText = reason
+		if len(reason) == 0 {
+			result.StopReason = Async_stopped_no_reason
+		} else if !ok {
+			result.StopReason = Async_stopped_unknown
 		} else {
 			result.StopReason = sr
 		}
 		return &result, nil
 	case Async_thread_group_started:
-		result.ThreadGroupid, _ = params["id"]
-		fmt.Sscanf(params["pid"], "%d", &result.Pid)
+		result.ThreadGroupid = params.Get("id").String()
+		fmt.Sscanf(params.Get("pid").String(), "%d", &result.Pid)
 	case Async_thread_group_exited:
-		result.ThreadGroupid, _ = params["id"]
-		fmt.Sscanf(params["exit-code"], "%d", &result.ExitCode)
+		result.ThreadGroupid = params.Get("id").String()
+		result.ExitCode, _ = exitCode(params)
 	case Async_thread_exited, Async_thread_created, Async_thread_selected:
-		result.ThreadId, _ = params["id"]
-		result.ThreadGroupid, _ = params["gid"]
+		result.ThreadId = params.Get("id").String()
+		result.ThreadGroupid = params.Get("group-id").String()
 	case Async_thread_group_added, Async_thread_group_removed:
-		result.ThreadGroupid, _ = params["id"]
+		result.ThreadGroupid = params.Get("id").String()
 	case Async_library_loaded, Async_library_unloaded:
 		break
 	case Async_traceframe_changed:
-		fmt.Sscanf(params["num"], "%d", &result.TraceFrameNumber)
-		fmt.Sscanf(params["tracepoint"], "%d", &result.TracePointNumber)
+		fmt.Sscanf(params.Get("num").String(), "%d", &result.TraceFrameNumber)
+		fmt.Sscanf(params.Get("tracepoint").String(), "%d", &result.TracePointNumber)
 	case Async_tsv_created, Async_tsv_deleted, Async_tsv_modified:
-		result.TsvName, _ = params["name"]
-		result.TsvInitial, _ = params["initial"]
-		result.TsvValue, _ = params["current"]
+		result.TsvName = params.Get("name").String()
+		result.TsvInitial = params.Get("initial").String()
+		result.TsvValue = params.Get("current").String()
 	case Async_record_started, Async_record_stopped:
-		result.ThreadGroupid, _ = params["thread-group"]
+		result.ThreadGroupid = params.Get("thread-group").String()
+		result.RecordMethod = params.Get("method").String()
+		result.RecordFormat = params.Get("format").String()
 	case Async_cmd_param_changed:
-		result.CmdParam, _ = params["param"]
-		result.CmdValue, _ = params["value"]
+		result.CmdParam = params.Get("param").String()
+		result.CmdValue = params.Get("value").String()
 	case Async_memory_changed:
-		result.ThreadGroupid, _ = params["thread-group"]
-		fmt.Sscanf(params["addr"], "%d", result.MemoryAddress)
-		fmt.Sscanf(params["len"], "%d", result.MemoryLen)
-		_, result.MemoryTypeCode = params["type"]
+		result.ThreadGroupid = params.Get("thread-group").String()
+		result.MemoryAddress, _ = params.Get("addr").Uint64()
+		result.MemoryLen, _ = params.Get("len").Int()
+		result.MemoryTypeCode = params.Get("type").String() == "code"
 	default:
 		return nil, fmt.Errorf("unknown async message: %s", res.Line())
 	}
@@ -813,36 +954,31 @@ func createAsync(gdb *GDB, res *gdb_async) (*GDBEvent, error) {
 }
 
 func createResult(res *gdb_result) (*GDBResult, error) {
+	if res.err != nil {
+		return nil, res.err
+	}
 	var result GDBResult
-	if strings.HasPrefix(res.Line(), Result_done.String()) {
-		parts := strings.SplitN(res.Line(), ",", 2)
-		if len(parts) > 1 {
-			result.Results = parts[1]
-		}
-		result.Type = Result_done
-		return &result, nil
-	} else if strings.HasPrefix(res.Line(), Result_running.String()) {
-		result.Type = Result_running
-		return &result, nil
-	} else if strings.HasPrefix(res.Line(), Result_connected.String()) {
-		result.Type = Result_connected
-		return &result, nil
-	} else if strings.HasPrefix(res.Line(), Result_error.String()) {
-		parts := strings.SplitN(res.Line(), ",", 2)
-		if len(parts) > 1 {
-			result.ErrorMessage = parts[1]
-		}
-		result.Type = Result_error
-		return &result, nil
-	} else if strings.HasPrefix(res.Line(), Result_exit.String()) {
-		result.Type = Result_exit
-		return &result, nil
+	rt, ok := ResultTypeWithName(res.class)
+	if !ok {
+		return nil, fmt.Errorf("unknown result indication '%s'", res.Line())
 	}
-	return nil, fmt.Errorf("unknown result indication '%s'", res.Line())
+	result.Type = rt
+	result.Console = res.console
+	result.Log = res.log
+	result.Results = res.raw
+	result.Values = res.results
+	if rt == Result_error {
+		result.ErrorMessage = res.results.Get("msg").String()
+	}
+	return &result, nil
 }
 
 func (gdb *GDB) Exec_arguments(args ...string) (*GDBResult, error) {
-	c := newCommand("exec-arguments")
+	return gdb.Exec_argumentsContext(context.Background(), args...)
+}
+
+func (gdb *GDB) Exec_argumentsContext(ctx context.Context, args ...string) (*GDBResult, error) {
+	c := newCommandContext(ctx, "exec-arguments")
 	for _, a := range args {
 		c.add_param(a)
 	}
@@ -850,123 +986,228 @@ func (gdb *GDB) Exec_arguments(args ...string) (*GDBResult, error) {
 }
 
 func (gdb *GDB) Environment_cd(dir string) (*GDBResult, error) {
-	c := newCommand("environment-cd").add_param(dir)
+	return gdb.Environment_cdContext(context.Background(), dir)
+}
+
+func (gdb *GDB) Environment_cdContext(ctx context.Context, dir string) (*GDBResult, error) {
+	c := newCommandContext(ctx, "environment-cd").add_param(dir)
 	return gdb.send(c)
 }
 
 func (gdb *GDB) Environment_directory(reset bool, dirs ...string) (string, error) {
-	return gdb.environment_path_query("environment-directory", "source-path=", reset, dirs...)
+	return gdb.Environment_directoryContext(context.Background(), reset, dirs...)
+}
+func (gdb *GDB) Environment_directoryContext(ctx context.Context, reset bool, dirs ...string) (string, error) {
+	return gdb.environment_path_query(ctx, "environment-directory", "source-path", reset, dirs...)
 }
 func (gdb *GDB) Environment_path(reset bool, dirs ...string) (string, error) {
-	return gdb.environment_path_query("environment-path", "path=", reset, dirs...)
+	return gdb.Environment_pathContext(context.Background(), reset, dirs...)
+}
+func (gdb *GDB) Environment_pathContext(ctx context.Context, reset bool, dirs ...string) (string, error) {
+	return gdb.environment_path_query(ctx, "environment-path", "path", reset, dirs...)
 }
 func (gdb *GDB) Environment_pwd() (string, error) {
-	return gdb.environment_path_query("environment-pwd", "cwd=", false, []string{}...)
+	return gdb.Environment_pwdContext(context.Background())
 }
-func (gdb *GDB) environment_path_query(gfunc string, prefix string, reset bool, dirs ...string) (string, error) {
-	c := newCommand(gfunc).add_option_when(reset, "-r")
+func (gdb *GDB) Environment_pwdContext(ctx context.Context) (string, error) {
+	return gdb.environment_path_query(ctx, "environment-pwd", "cwd", false, []string{}...)
+}
+func (gdb *GDB) environment_path_query(ctx context.Context, gfunc string, field string, reset bool, dirs ...string) (string, error) {
+	c := newCommandContext(ctx, gfunc).add_option_when(reset, "-r")
 	for _, d := range dirs {
-		c.add_param(fmt.Sprintf("\"%s\"", d))
+		c.add_quoted_param(d)
 	}
 	res, err := gdb.send(c)
 	if err != nil {
 		return "", err
 	}
-	sourcep := cutoff(res.Results, prefix, true)
-	return sourcep, nil
+	sourcep := res.Values.Get(field)
+	if sourcep.Kind() != Value_const {
+		return "", fmt.Errorf("%s: missing %s in result: %s", gfunc, field, res.Results)
+	}
+	return sourcep.String(), nil
 }
 
-func reverse_command(gdb *GDB, cmd string, reverse bool) (*GDBResult, error) {
-	c := newCommand(cmd)
+func reverse_command(ctx context.Context, gdb *GDB, cmd string, reverse bool, opts []Option) (*GDBResult, error) {
+	c := newCommandContext(ctx, cmd)
 	if reverse {
 		c.add_option("--reverse")
 	}
+	c.add_options(opts)
 	return gdb.send(c)
 }
 
 func (gdb *GDB) Inferior_tty_set(tty string) (*GDBResult, error) {
-	c := newCommand("inferior-tty-set")
+	return gdb.Inferior_tty_setContext(context.Background(), tty)
+}
+
+func (gdb *GDB) Inferior_tty_setContext(ctx context.Context, tty string) (*GDBResult, error) {
+	c := newCommandContext(ctx, "inferior-tty-set")
 	c.add_param(tty)
 	return gdb.send(c)
 }
 
 func (gdb *GDB) Set(key, val string) (*GDBResult, error) {
-	c := newCommand("gdb-set")
+	return gdb.SetContext(context.Background(), key, val)
+}
+
+func (gdb *GDB) SetContext(ctx context.Context, key, val string) (*GDBResult, error) {
+	c := newCommandContext(ctx, "gdb-set")
 	c.add_param(key)
 	c.add_param(val)
 	return gdb.send(c)
 }
 
 func (gdb *GDB) SetAsync() (*GDBResult, error) {
-	return gdb.Set("target-async", "1")
+	return gdb.SetAsyncContext(context.Background())
+}
+
+func (gdb *GDB) SetAsyncContext(ctx context.Context) (*GDBResult, error) {
+	return gdb.SetContext(ctx, "target-async", "1")
 }
 
 func (gdb *GDB) SetNonstop() (*GDBResult, error) {
-	return gdb.Set("non-stop", "on")
+	return gdb.SetNonstopContext(context.Background())
+}
+
+func (gdb *GDB) SetNonstopContext(ctx context.Context) (*GDBResult, error) {
+	return gdb.SetContext(ctx, "non-stop", "on")
+}
+
+func (gdb *GDB) Exec_next(reverse bool, opts ...Option) (*GDBResult, error) {
+	return gdb.Exec_nextContext(context.Background(), reverse, opts...)
+}
+
+func (gdb *GDB) Exec_nextContext(ctx context.Context, reverse bool, opts ...Option) (*GDBResult, error) {
+	return reverse_command(ctx, gdb, "exec-next", reverse, opts)
+}
+
+func (gdb *GDB) Exec_nexti(reverse bool, opts ...Option) (*GDBResult, error) {
+	return gdb.Exec_nextiContext(context.Background(), reverse, opts...)
+}
+
+func (gdb *GDB) Exec_nextiContext(ctx context.Context, reverse bool, opts ...Option) (*GDBResult, error) {
+	return reverse_command(ctx, gdb, "exec-next-instruction", reverse, opts)
+}
+
+func (gdb *GDB) Exec_step(reverse bool, opts ...Option) (*GDBResult, error) {
+	return gdb.Exec_stepContext(context.Background(), reverse, opts...)
+}
+
+func (gdb *GDB) Exec_stepContext(ctx context.Context, reverse bool, opts ...Option) (*GDBResult, error) {
+	return reverse_command(ctx, gdb, "exec-step", reverse, opts)
 }
 
-func (gdb *GDB) Exec_next(reverse bool) (*GDBResult, error) {
-	return reverse_command(gdb, "exec-next", reverse)
+func (gdb *GDB) Exec_stepi(reverse bool, opts ...Option) (*GDBResult, error) {
+	return gdb.Exec_stepiContext(context.Background(), reverse, opts...)
 }
 
-func (gdb *GDB) Exec_nexti(reverse bool) (*GDBResult, error) {
-	return reverse_command(gdb, "exec-next-instruction", reverse)
+func (gdb *GDB) Exec_stepiContext(ctx context.Context, reverse bool, opts ...Option) (*GDBResult, error) {
+	return reverse_command(ctx, gdb, "exec-step-instruction", reverse, opts)
 }
-func (gdb *GDB) Exec_step(reverse bool) (*GDBResult, error) {
-	return reverse_command(gdb, "exec-step", reverse)
+
+func (gdb *GDB) Exec_finish(reverse bool, opts ...Option) (*GDBResult, error) {
+	return gdb.Exec_finishContext(context.Background(), reverse, opts...)
 }
 
-func (gdb *GDB) Exec_stepi(reverse bool) (*GDBResult, error) {
-	return reverse_command(gdb, "exec-step-instruction", reverse)
+func (gdb *GDB) Exec_finishContext(ctx context.Context, reverse bool, opts ...Option) (*GDBResult, error) {
+	return reverse_command(ctx, gdb, "exec-finish", reverse, opts)
 }
 
-func (gdb *GDB) Exec_finish(reverse bool) (*GDBResult, error) {
-	return reverse_command(gdb, "exec-finish", reverse)
+func (gdb *GDB) Exec_return(opts ...Option) (*GDBResult, error) {
+	return gdb.Exec_returnContext(context.Background(), opts...)
 }
 
-func (gdb *GDB) Exec_return() (*GDBResult, error) {
-	c := newCommand("exec-return")
+func (gdb *GDB) Exec_returnContext(ctx context.Context, opts ...Option) (*GDBResult, error) {
+	c := newCommandContext(ctx, "exec-return")
+	c.add_options(opts)
 	return gdb.send(c)
 }
 
-func (gdb *GDB) Exec_run(all bool, start bool, threadgroup *int) (*GDBResult, error) {
-	c := newCommand("exec-run")
+func (gdb *GDB) Exec_run(all bool, start bool, threadgroup string, opts ...Option) (*GDBResult, error) {
+	return gdb.Exec_runContext(context.Background(), all, start, threadgroup, opts...)
+}
+
+func (gdb *GDB) Exec_runContext(ctx context.Context, all bool, start bool, threadgroup string, opts ...Option) (*GDBResult, error) {
+	c := newCommandContext(ctx, "exec-run")
 	if all {
 		c.add_option("--all")
 	}
 	if start {
 		c.add_option("--start")
 	}
-	if threadgroup != nil {
-		c.add_option_intvalue("--thread-group", threadgroup)
+	if len(threadgroup) > 0 {
+		c.add_option_value("--thread-group", threadgroup)
 	}
+	c.add_options(opts)
 	return gdb.send(c)
 }
-func (gdb *GDB) Exec_interrupt(all bool, threadgroup *int) (*GDBResult, error) {
-	c := newCommand("exec-interrupt")
+
+func (gdb *GDB) Exec_interrupt(all bool, threadgroup string, opts ...Option) (*GDBResult, error) {
+	return gdb.Exec_interruptContext(context.Background(), all, threadgroup, opts...)
+}
+
+func (gdb *GDB) Exec_interruptContext(ctx context.Context, all bool, threadgroup string, opts ...Option) (*GDBResult, error) {
+	c := newCommandContext(ctx, "exec-interrupt")
 	if all {
 		c.add_option("--all")
 	}
-	if threadgroup != nil {
-		c.add_option_intvalue("--thread-group", threadgroup)
+	if len(threadgroup) > 0 {
+		c.add_option_value("--thread-group", threadgroup)
 	}
+	c.add_options(opts)
 	return gdb.send(c)
 }
 
-func (gdb *GDB) Exec_continue(all bool, reverse bool, threadgroup *int) (*GDBResult, error) {
-	c := newCommand("exec-continue")
+func (gdb *GDB) Exec_continue(all bool, reverse bool, threadgroup string, opts ...Option) (*GDBResult, error) {
+	return gdb.Exec_continueContext(context.Background(), all, reverse, threadgroup, opts...)
+}
+
+func (gdb *GDB) Exec_continueContext(ctx context.Context, all bool, reverse bool, threadgroup string, opts ...Option) (*GDBResult, error) {
+	c := newCommandContext(ctx, "exec-continue")
 	if all {
 		c.add_option("--all")
 	}
 	if reverse {
 		c.add_option("--reverse")
 	}
-	if threadgroup != nil {
-		c.add_option_intvalue("--thread-group", threadgroup)
+	if len(threadgroup) > 0 {
+		c.add_option_value("--thread-group", threadgroup)
 	}
+	c.add_options(opts)
 	return gdb.send(c)
 }
 
+// Exec_until runs the program until it reaches the location or a line greater
+// than the current line, or the current frame returns.
+func (gdb *GDB) Exec_until(location string, opts ...Option) (*GDBResult, error) {
+	return gdb.Exec_untilContext(context.Background(), location, opts...)
+}
+
+func (gdb *GDB) Exec_untilContext(ctx context.Context, location string, opts ...Option) (*GDBResult, error) {
+	c := newCommandContext(ctx, "exec-until")
+	c.add_options(opts)
+	if len(location) > 0 {
+		c.add_param(location)
+	}
+	return gdb.send(c)
+}
+
+// Exec_jump continues the program at the location.
+func (gdb *GDB) Exec_jump(location string, opts ...Option) (*GDBResult, error) {
+	return gdb.Exec_jumpContext(context.Background(), location, opts...)
+}
+
+func (gdb *GDB) Exec_jumpContext(ctx context.Context, location string, opts ...Option) (*GDBResult, error) {
+	c := newCommandContext(ctx, "exec-jump")
+	c.add_options(opts)
+	return gdb.send(c.add_param(location))
+}
+
 func (gdb *GDB) Gdb_exit() {
-	gdb.send(newCommand("gdb-exit"))
+	gdb.Gdb_exitContext(context.Background())
+}
+
+func (gdb *GDB) Gdb_exitContext(ctx context.Context) {
+	gdb.send(newCommandContext(ctx, "gdb-exit"))
 }
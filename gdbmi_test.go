@@ -1,12 +1,20 @@
 package gdbmi
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	_ "net/http"
 	_ "net/http/pprof"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func dummyTokenGenerator() int64 {
@@ -50,7 +58,11 @@ func TestEnvironment(t *testing.T) {
 		{result: "cwd=\"/a/b/c\"", expected: "/a/b/c", f: pwd},
 	}
 	for _, td := range testdata {
-		res1 := GDBResult{Type: Result_done, Results: td.result, ErrorMessage: ""}
+		vals, err := parseResultList(td.result)
+		if err != nil {
+			t.Fatalf("cannot parse testdata '%s': %s", td.result, err)
+		}
+		res1 := GDBResult{Type: Result_done, Results: td.result, Values: vals, ErrorMessage: ""}
 		gdb.send = createSender(&res1, nil)
 		envpath, _ := td.f()
 		if !equals(envpath, td.expected) {
@@ -87,14 +99,15 @@ func TestNewGDB(t *testing.T) {
 	}*/
 	//r, err := gdb.Break_commands(bp.Number, "continue")
 	//log.Printf("break_commands: %+v, %s", r, err)
-	res, err := gdb.Exec_run(false, false, nil)
+	events := gdb.Subscribe(EventFilter{}, 16, Backpressure_block)
+	res, err := gdb.Exec_run(false, false, "")
 	if err != nil {
 		log.Printf("exec error: %+s", err)
 	} else {
 		log.Printf("exec result: %+v", res)
 	}
 	go func() {
-		for ev := range gdb.Event {
+		for ev := range events.C {
 			log.Printf("received: %+v", ev)
 			if ev.StopReason == Async_stopped_exited ||
 				ev.StopReason == Async_stopped_exited_normally ||
@@ -117,3 +130,160 @@ func TestNewGDB(t *testing.T) {
 	}()
 	//http.ListenAndServe("localhost:6060", nil)
 }
+
+func TestCommandTimeout(t *testing.T) {
+	tokenGenerator = dummyTokenGenerator
+	gdb := NewGDB("unused")
+	canceled := make(chan int64, 1)
+	go func() {
+		// a debugger which never answers
+		<-gdb.commands
+		canceled <- <-gdb.cancel
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := gdb.Stack_info_depthContext(ctx, nil)
+	var terr *TimeoutError
+	if !errors.As(err, &terr) || !terr.Timeout() || terr.Command != "stack-info-depth" {
+		t.Fatalf("command should time out: %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("timeout should wrap the context error: %v", err)
+	}
+	select {
+	case tok := <-canceled:
+		if tok != 1 {
+			t.Errorf("wrong command canceled: %d", tok)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("command was not removed from the open commands")
+	}
+}
+
+type pipeCloser struct {
+	*io.PipeWriter
+}
+
+// fakeGDB runs the dispatcher of a debugger without a GDB process. The
+// commands sent to GDB can be read from the returned channel, the output of
+// GDB is simulated with the returned function.
+func fakeGDB() (*GDB, <-chan string, func(string)) {
+	gdb := NewGDB("unused")
+	r, w := io.Pipe()
+	gdb.stdin = pipeCloser{w}
+	sent := make(chan string, 10)
+	go func() {
+		sc := bufio.NewScanner(r)
+		for sc.Scan() {
+			sent <- sc.Text()
+		}
+	}()
+	go gdb.dispatch()
+	output := func(line string) {
+		if rsp := parseOutputLine(line); rsp != nil {
+			gdb.result <- rsp
+		}
+	}
+	return gdb, sent, output
+}
+
+// An exchange is a command which the fake GDB expects and the output lines it
+// answers with.
+type exchange struct {
+	command string
+	output  []string
+}
+
+// expect returns the exchange of the command (without token) and its output.
+func expect(command string, output ...string) exchange {
+	return exchange{command, output}
+}
+
+// fakeSession runs a fake GDB which expects the commands of the exchanges in
+// this order and answers them with their output. The commands are compared
+// without their token and repeated spaces; all tokens are 1. Further commands
+// fail.
+func fakeSession(t *testing.T, exchanges ...exchange) *GDB {
+	t.Helper()
+	tokenGenerator = dummyTokenGenerator
+	gdb, sent, output := fakeGDB()
+	go func() {
+		for _, e := range exchanges {
+			cmd := strings.Join(strings.Fields(strings.TrimLeft(<-sent, "0123456789")), " ")
+			if cmd != e.command {
+				t.Errorf("command %q sent, expected %q", cmd, e.command)
+			}
+			for _, l := range e.output {
+				output(l)
+			}
+		}
+		for cmd := range sent {
+			output(fmt.Sprintf(`1^error,msg="unexpected command %s"`, strings.Trim(strconv.Quote(cmd), `"`)))
+		}
+	}()
+	return gdb
+}
+
+func TestDispatcher(t *testing.T) {
+	tokenGenerator = dummyTokenGenerator
+	gdb, sent, output := fakeGDB()
+	go func() {
+		<-sent
+		output(`~"first line\n"`)
+		output(`~"second line\n"`)
+		output(`1^done,value="42"`)
+	}()
+	res, err := gdb.Exec(context.Background(), "interpreter-exec", nil, "console", "print 42")
+	if err != nil {
+		t.Fatalf("command failed: %s", err)
+	}
+	if len(res.Console) != 2 || res.Console[1] != "second line\n" || res.Values.Get("value").String() != "42" {
+		t.Errorf("wrong result: %+v", res)
+	}
+
+	go func() {
+		<-sent
+		close(gdb.result)
+	}()
+	if _, err = gdb.Stack_info_depth(nil); err != ErrDebuggerExited {
+		t.Errorf("pending command should fail when GDB exits: %v", err)
+	}
+	if _, err = gdb.Stack_info_depth(nil); err != ErrDebuggerExited {
+		t.Errorf("command should fail after GDB exited: %v", err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	gdb, sent, _ := fakeGDB()
+	go func() {
+		<-sent
+		gdb.Close()
+		gdb.Close()
+	}()
+	if _, err := gdb.Stack_info_depth(nil); err != ErrDebuggerExited {
+		t.Errorf("pending command should fail when the debugger is closed: %v", err)
+	}
+}
+
+func TestTokenGenerator(t *testing.T) {
+	tokens := make(chan int64, 1000)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				tokens <- counterTokenGenerator()
+			}
+		}()
+	}
+	wg.Wait()
+	close(tokens)
+	seen := make(map[int64]bool)
+	for tok := range tokens {
+		if seen[tok] {
+			t.Fatalf("token %d generated twice", tok)
+		}
+		seen[tok] = true
+	}
+}
@@ -0,0 +1,375 @@
+package gdbmi
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	// the output of a run which is not read is dropped beyond this size
+	inferiorBufferSize = 1 << 20
+	// time to read the remaining output after the program exited
+	inferiorDrainTime = 200 * time.Millisecond
+)
+
+// An InferiorLine is a line of output of the debugged program together with
+// the time it was read from the terminal.
+type InferiorLine struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
+type inferiorChunk struct {
+	time time.Time
+	data []byte
+}
+
+// InferiorOutput is the terminal output (stdout and stderr) of one run of the
+// debugged program. It returns io.EOF when the program has exited and all of
+// its output was read. Read, ReadLine and Lines consume the same output, so
+// only one of them should be used. All inferiors write to the same terminal,
+// so the output of a run contains the output of all inferiors which run at the
+// same time and ends when the last of them has exited.
+type InferiorOutput struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	chunks  []inferiorChunk
+	size    int
+	started bool
+	eof     bool
+	closed  bool
+	done    chan struct{}
+}
+
+func newInferiorOutput() *InferiorOutput {
+	o := &InferiorOutput{done: make(chan struct{})}
+	o.cond = sync.NewCond(&o.mu)
+	return o
+}
+
+func (o *InferiorOutput) write(t time.Time, data []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return
+	}
+	o.chunks = append(o.chunks, inferiorChunk{t, append([]byte(nil), data...)})
+	o.size += len(data)
+	for o.size > inferiorBufferSize && len(o.chunks) > 1 {
+		o.size -= len(o.chunks[0].data)
+		o.chunks = o.chunks[1:]
+	}
+	o.cond.Broadcast()
+}
+
+func (o *InferiorOutput) close() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.eof = true
+	o.cond.Broadcast()
+}
+
+// Close stops reading the output: the rest of the output is dropped, Read and
+// ReadLine return os.ErrClosed and the channel of Lines is closed.
+func (o *InferiorOutput) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.closed {
+		o.closed = true
+		o.chunks = nil
+		o.size = 0
+		close(o.done)
+		o.cond.Broadcast()
+	}
+	return nil
+}
+
+func (o *InferiorOutput) finished() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.eof
+}
+
+// Read reads the output of the program; it blocks until output is available.
+func (o *InferiorOutput) Read(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for len(o.chunks) == 0 {
+		if o.closed {
+			return 0, os.ErrClosed
+		}
+		if o.eof {
+			return 0, io.EOF
+		}
+		o.cond.Wait()
+	}
+	n := 0
+	for n < len(p) && len(o.chunks) > 0 {
+		c := &o.chunks[0]
+		m := copy(p[n:], c.data)
+		n += m
+		o.size -= m
+		c.data = c.data[m:]
+		if len(c.data) == 0 {
+			o.chunks = o.chunks[1:]
+		}
+	}
+	return n, nil
+}
+
+// ReadLine returns the next line of output including the line feed. The time of
+// the line is the time when its first character was read. The last line may
+// have no line feed.
+func (o *InferiorOutput) ReadLine() (InferiorLine, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for {
+		if o.closed {
+			return InferiorLine{}, os.ErrClosed
+		}
+		for i, c := range o.chunks {
+			if idx := bytes.IndexByte(c.data, '\n'); idx >= 0 {
+				return o.takeLine(i, idx+1), nil
+			}
+		}
+		if o.eof {
+			if len(o.chunks) > 0 {
+				last := len(o.chunks) - 1
+				return o.takeLine(last, len(o.chunks[last].data)), nil
+			}
+			return InferiorLine{}, io.EOF
+		}
+		o.cond.Wait()
+	}
+}
+
+// takeLine removes the chunks up to chunk i and the first n bytes of chunk i
+// and returns them as a line
+func (o *InferiorOutput) takeLine(i int, n int) InferiorLine {
+	var buf bytes.Buffer
+	line := InferiorLine{Time: o.chunks[0].time}
+	for _, c := range o.chunks[:i] {
+		buf.Write(c.data)
+	}
+	buf.Write(o.chunks[i].data[:n])
+	o.chunks[i].data = o.chunks[i].data[n:]
+	if len(o.chunks[i].data) == 0 {
+		i++
+	}
+	o.chunks = o.chunks[i:]
+	o.size -= buf.Len()
+	line.Text = buf.String()
+	return line
+}
+
+// Lines returns a channel with the lines of the output. The channel is closed
+// when the program has exited or the output is closed.
+func (o *InferiorOutput) Lines() <-chan InferiorLine {
+	c := make(chan InferiorLine)
+	go func() {
+		defer close(c)
+		for {
+			l, err := o.ReadLine()
+			if err != nil {
+				return
+			}
+			select {
+			case c <- l:
+			case <-o.done:
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// inferiorIO pumps the output of the terminal of the program to the output of
+// the current run.
+type inferiorIO struct {
+	sync.Mutex
+	out   *InferiorOutput
+	start chan bool
+	// the output of a run is read from the terminal
+	active bool
+	// the program exited, the rest of its output is read
+	draining bool
+	// the running inferiors, which share the terminal
+	running map[string]bool
+}
+
+// output returns the output of the current run or creates the output of the next run
+func (inf *inferiorIO) output() *InferiorOutput {
+	inf.Lock()
+	defer inf.Unlock()
+	if inf.out == nil || inf.out.finished() {
+		inf.out = newInferiorOutput()
+	}
+	return inf.out
+}
+
+// started is called when an inferior is started. If another inferior is
+// running, its output is continued.
+func (inf *inferiorIO) started(id string) {
+	inf.Lock()
+	if inf.running == nil {
+		inf.running = make(map[string]bool)
+	}
+	inf.running[id] = true
+	join := inf.active && !inf.draining
+	inf.active = true
+	inf.draining = false
+	inf.Unlock()
+	if join {
+		return
+	}
+	select {
+	case inf.start <- true:
+	default:
+	}
+}
+
+// finish ends the output of the current run, if it was started
+func (inf *inferiorIO) finish() {
+	inf.Lock()
+	defer inf.Unlock()
+	if len(inf.start) == 0 {
+		// no next run was started yet
+		inf.active = false
+		inf.draining = false
+	}
+	if inf.out != nil && inf.out.started {
+		inf.out.close()
+	}
+}
+
+// exited is called when an inferior exited. When the last running inferior
+// exited, not all of its output may be read, so the output of the run is
+// finished when nothing was read for some time. If the terminal does not
+// support deadlines, the output ends when the program closes the terminal. It
+// is ignored if the output of the run already ended, because the program
+// closed the terminal.
+func (inf *inferiorIO) exited(tty *os.File, id string) {
+	inf.Lock()
+	defer inf.Unlock()
+	delete(inf.running, id)
+	if !inf.active || len(inf.running) > 0 {
+		return
+	}
+	inf.draining = true
+	tty.SetReadDeadline(time.Now().Add(inferiorDrainTime))
+}
+
+// read is called after output was read; while draining, the deadline is
+// extended, so the output ends when the terminal is idle.
+func (inf *inferiorIO) read(tty *os.File) {
+	inf.Lock()
+	defer inf.Unlock()
+	if inf.draining {
+		tty.SetReadDeadline(time.Now().Add(inferiorDrainTime))
+	}
+}
+
+func (inf *inferiorIO) waitForStart(tty *os.File, quit chan bool) bool {
+	select {
+	case <-inf.start:
+	case <-quit:
+		return false
+	}
+	out := inf.output()
+	inf.Lock()
+	defer inf.Unlock()
+	if inf.draining {
+		// the program exited before its output was read
+		tty.SetReadDeadline(time.Now().Add(inferiorDrainTime))
+	} else {
+		// a deadline of the last run must not end this run
+		tty.SetReadDeadline(time.Time{})
+	}
+	out.mu.Lock()
+	out.started = true
+	out.mu.Unlock()
+	return true
+}
+
+func (gdb *GDB) pumpInferiorOutput(tty *os.File) {
+	inf := &gdb.inferior
+	defer inf.finish()
+	if !inf.waitForStart(tty, gdb.quit) {
+		return
+	}
+	buf := make([]byte, 4096)
+	for {
+		n, err := tty.Read(buf)
+		if n > 0 {
+			inf.output().write(time.Now(), buf[:n])
+			inf.read(tty)
+		}
+		if err == nil {
+			continue
+		}
+		switch {
+		case errors.Is(err, os.ErrDeadlineExceeded):
+			// the program exited and its terminal is idle
+		case errors.Is(err, syscall.EIO):
+			// the program closed its terminal
+		default:
+			return
+		}
+		inf.finish()
+		if !inf.waitForStart(tty, gdb.quit) {
+			return
+		}
+	}
+}
+
+// pollableTerminal returns a non-blocking copy of the terminal, so that reads
+// can have deadlines. Calling Fd() switches a file to blocking mode.
+func pollableTerminal(tty *os.File) (*os.File, error) {
+	fd, err := syscall.Dup(int(tty.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	tty.Close()
+	return os.NewFile(uintptr(fd), tty.Name()), nil
+}
+
+// InferiorOutput returns the terminal output of the current run of the program
+// or of the next run, if the program is not running.
+func (gdb *GDB) InferiorOutput() *InferiorOutput {
+	return gdb.inferior.output()
+}
+
+// SetInferiorWindowSize sets the size of the terminal of the debugged program.
+func (gdb *GDB) SetInferiorWindowSize(rows, cols uint16) error {
+	if gdb.tty == nil {
+		return errors.New("gdbmi: the program has no terminal")
+	}
+	ws := struct {
+		rows, cols, xpixel, ypixel uint16
+	}{rows, cols, 0, 0}
+	rc, err := gdb.tty.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var e syscall.Errno
+	err = rc.Control(func(fd uintptr) {
+		_, _, e = syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCSWINSZ, uintptr(unsafe.Pointer(&ws)))
+	})
+	if err != nil {
+		return err
+	}
+	if e != 0 {
+		return e
+	}
+	return nil
+}
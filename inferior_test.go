@@ -0,0 +1,148 @@
+package gdbmi
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jayschwa/go-pty"
+)
+
+func TestInferiorOutput(t *testing.T) {
+	o := newInferiorOutput()
+	now := time.Now()
+	o.write(now, []byte("first\nsec"))
+	o.write(now.Add(time.Second), []byte("ond\nthi"))
+	o.write(now.Add(2*time.Second), []byte("rd"))
+	o.close()
+	expected := []InferiorLine{
+		{now, "first\n"},
+		{now, "second\n"},
+		{now.Add(time.Second), "third"},
+	}
+	for i, e := range expected {
+		l, err := o.ReadLine()
+		if err != nil {
+			t.Fatalf("line %d: %s", i, err)
+		}
+		if l.Text != e.Text || !l.Time.Equal(e.Time) {
+			t.Errorf("line %d: expected %v, got %v", i, e, l)
+		}
+	}
+	if _, err := o.ReadLine(); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
+
+func TestInferiorTerminal(t *testing.T) {
+	gdb := NewGDB("")
+	master, name, err := pty.Open()
+	if err != nil {
+		t.Skipf("no pty: %s", err)
+	}
+	if master, err = pollableTerminal(master); err != nil {
+		t.Fatalf("cannot use pty: %s", err)
+	}
+	gdb.tty = master
+	go gdb.pumpInferiorOutput(master)
+	defer gdb.Close()
+
+	if err := gdb.SetInferiorWindowSize(24, 100); err != nil {
+		t.Fatalf("cannot set window size: %s", err)
+	}
+	// the output ends when the program closes the terminal or when it exited
+	// and the terminal is idle
+	runs := []struct {
+		text   []string
+		close  bool
+		exited bool
+	}{
+		{[]string{"first run"}, true, false},
+		// the program exited after it closed the terminal
+		{[]string{"second run"}, true, true},
+		// output after the exit is read until the terminal is idle
+		{[]string{"third ", "run ", "drains"}, false, true},
+		{[]string{"fourth run"}, true, true},
+	}
+	for _, r := range runs {
+		out := gdb.InferiorOutput()
+		tty, err := os.OpenFile(name, os.O_RDWR|syscall.O_NOCTTY, 0)
+		if err != nil {
+			t.Fatalf("cannot open terminal: %s", err)
+		}
+		gdb.inferior.started("i1")
+		for i, text := range r.text {
+			if i > 0 {
+				time.Sleep(inferiorDrainTime / 4)
+			}
+			tty.Write([]byte(text))
+			if i == 0 && !r.close && r.exited {
+				gdb.inferior.exited(master, "i1")
+			}
+		}
+		if r.close {
+			tty.Close()
+		}
+		b, err := ioutil.ReadAll(out)
+		if err != nil {
+			t.Fatalf("cannot read output: %s", err)
+		}
+		if expected := strings.Join(r.text, ""); string(b) != expected {
+			t.Errorf("expected '%s', got '%s'", expected, string(b))
+		}
+		if r.close && r.exited {
+			gdb.inferior.exited(master, "i1")
+		}
+		if !r.close {
+			tty.Close()
+		}
+	}
+
+	// the output continues until the last inferior exited
+	out := gdb.InferiorOutput()
+	tty, err := os.OpenFile(name, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatalf("cannot open terminal: %s", err)
+	}
+	defer tty.Close()
+	gdb.inferior.started("i1")
+	gdb.inferior.started("i2")
+	tty.Write([]byte("parent "))
+	gdb.inferior.exited(master, "i2")
+	time.Sleep(2 * inferiorDrainTime)
+	tty.Write([]byte("child"))
+	gdb.inferior.exited(master, "i1")
+	if b, err := ioutil.ReadAll(out); err != nil || string(b) != "parent child" {
+		t.Errorf("expected the output of both inferiors, got '%s', %v", string(b), err)
+	}
+}
+
+func TestInferiorOutputClose(t *testing.T) {
+	o := newInferiorOutput()
+	o.write(time.Now(), []byte("first\nsecond\n"))
+	lines := o.Lines()
+	if l := <-lines; l.Text != "first\n" {
+		t.Errorf("wrong line: %v", l)
+	}
+	o.Close()
+	// the goroutine of Lines may have read the second line before
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-lines:
+			if !ok {
+				if _, err := o.ReadLine(); !errors.Is(err, os.ErrClosed) {
+					t.Errorf("reading a closed output: %v", err)
+				}
+				return
+			}
+		case <-timeout:
+			t.Fatalf("the lines are not closed")
+		}
+	}
+}
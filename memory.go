@@ -0,0 +1,133 @@
+package gdbmi
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// A MemoryBlock is a readable part of the memory of the program.
+type MemoryBlock struct {
+	Begin    uint64 `json:"begin"`
+	Offset   uint64 `json:"offset"`
+	End      uint64 `json:"end"`
+	Contents []byte `json:"contents"`
+}
+
+// A MemoryError is returned when (a part of) the memory cannot be read or
+// written, e.g. because it is not mapped.
+type MemoryError struct {
+	Address uint64
+	Err     error
+}
+
+func (e *MemoryError) Error() string {
+	return fmt.Sprintf("gdbmi: cannot access memory at 0x%x", e.Address)
+}
+
+func (e *MemoryError) Unwrap() error {
+	return e.Err
+}
+
+// Data_read_memory_bytes reads count bytes of memory at the address (an
+// expression like "0x601040" or "&buf"). Only the readable blocks of the
+// range are returned.
+func (gdb *GDB) Data_read_memory_bytes(address string, count int) ([]MemoryBlock, error) {
+	return gdb.Data_read_memory_bytesContext(context.Background(), address, count)
+}
+
+func (gdb *GDB) Data_read_memory_bytesContext(ctx context.Context, address string, count int) ([]MemoryBlock, error) {
+	c := newCommandContext(ctx, "data-read-memory-bytes").add_quoted_param(address).add_param(fmt.Sprintf("%d", count))
+	res, err := gdb.send(c)
+	if err != nil {
+		return nil, err
+	}
+	var blocks []struct {
+		Begin    uint64 `mi:"begin"`
+		Offset   uint64 `mi:"offset"`
+		End      uint64 `mi:"end"`
+		Contents string `mi:"contents"`
+	}
+	if err := Unmarshal(res.Values.Get("memory"), &blocks); err != nil {
+		return nil, err
+	}
+	result := make([]MemoryBlock, len(blocks))
+	for i, b := range blocks {
+		result[i] = MemoryBlock{Begin: b.Begin, Offset: b.Offset, End: b.End}
+		if result[i].Contents, err = hex.DecodeString(b.Contents); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Data_write_memory_bytes writes the bytes to the memory at the address.
+func (gdb *GDB) Data_write_memory_bytes(address string, data []byte) (*GDBResult, error) {
+	return gdb.Data_write_memory_bytesContext(context.Background(), address, data)
+}
+
+func (gdb *GDB) Data_write_memory_bytesContext(ctx context.Context, address string, data []byte) (*GDBResult, error) {
+	c := newCommandContext(ctx, "data-write-memory-bytes").add_quoted_param(address)
+	return gdb.send(c.add_param(hex.EncodeToString(data)))
+}
+
+// ReadMemory reads n bytes at the address. If only the first part of the range
+// is readable, this part is returned with a *MemoryError for the first byte
+// which cannot be read.
+func (gdb *GDB) ReadMemory(ctx context.Context, address uint64, n int) ([]byte, error) {
+	blocks, err := gdb.Data_read_memory_bytesContext(ctx, fmt.Sprintf("0x%x", address), n)
+	var gerr *GDBError
+	if errors.As(err, &gerr) {
+		return nil, &MemoryError{address, gerr}
+	}
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	for _, b := range blocks {
+		if b.Begin != address+uint64(len(data)) {
+			break
+		}
+		data = append(data, b.Contents...)
+	}
+	if len(data) < n {
+		return data, &MemoryError{address + uint64(len(data)), nil}
+	}
+	return data, nil
+}
+
+// WriteMemory writes the data to the memory at the address.
+func (gdb *GDB) WriteMemory(ctx context.Context, address uint64, data []byte) error {
+	_, err := gdb.Data_write_memory_bytesContext(ctx, fmt.Sprintf("0x%x", address), data)
+	var gerr *GDBError
+	if errors.As(err, &gerr) {
+		return &MemoryError{address, gerr}
+	}
+	return err
+}
+
+// Memory is an io.ReaderAt and io.WriterAt for the memory of the program. The
+// offsets are the addresses (as unsigned numbers).
+type Memory struct {
+	gdb *GDB
+	ctx context.Context
+}
+
+// Memory returns the memory of the program, all reads and writes use the
+// context.
+func (gdb *GDB) Memory(ctx context.Context) *Memory {
+	return &Memory{gdb, ctx}
+}
+
+func (m *Memory) ReadAt(p []byte, off int64) (int, error) {
+	data, err := m.gdb.ReadMemory(m.ctx, uint64(off), len(p))
+	return copy(p, data), err
+}
+
+func (m *Memory) WriteAt(p []byte, off int64) (int, error) {
+	if err := m.gdb.WriteMemory(m.ctx, uint64(off), p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
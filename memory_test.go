@@ -0,0 +1,50 @@
+package gdbmi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	memoryChanged := `=memory-changed,thread-group="i1",addr="0xffffffffff600000",len="0x2",type="code"`
+	// the last 4 bytes are not mapped
+	partial := `1^done,memory=[{begin="0x0000000000601040",offset="0x0000000000000000",end="0x0000000000601044",contents="01020304"}]`
+	gdb := fakeSession(t,
+		expect(`-data-read-memory-bytes 0x601040 8`, partial),
+		expect(`-data-read-memory-bytes 0x601040 8`, partial),
+		expect(`-data-read-memory-bytes 0x0 4`, `1^error,msg="Unable to read memory."`),
+		expect(`-data-write-memory-bytes 0x601040 cafe`, memoryChanged, `1^done`),
+		expect(`-data-read-memory-bytes &buf 2`, `1^done,memory=[{begin="0x0000000000601040",offset="0x0000000000000000",end="0x0000000000601042",contents="cafe"}]`),
+		expect(`-data-write-memory-bytes &buf 00`, memoryChanged, `1^done`),
+	)
+	events := gdb.Subscribe(EventFilter{Types: []GDBAsyncType{Async_memory_changed}}, 2, Backpressure_block)
+	ctx := context.Background()
+	data, err := gdb.ReadMemory(ctx, 0x601040, 8)
+	var merr *MemoryError
+	if !bytes.Equal(data, []byte{1, 2, 3, 4}) || !errors.As(err, &merr) || merr.Address != 0x601044 {
+		t.Errorf("wrong partial read: %v, %v", data, err)
+	}
+	buf := make([]byte, 8)
+	if n, err := gdb.Memory(ctx).ReadAt(buf, 0x601040); n != 4 || err == nil {
+		t.Errorf("ReadAt should return the readable part: %d, %v", n, err)
+	}
+	if _, err := gdb.ReadMemory(ctx, 0, 4); !errors.As(err, &merr) || merr.Address != 0 {
+		t.Errorf("unreadable memory: %v", err)
+	}
+	if n, err := gdb.Memory(ctx).WriteAt([]byte{0xca, 0xfe}, 0x601040); n != 2 || err != nil {
+		t.Errorf("write failed: %d, %v", n, err)
+	}
+	blocks, err := gdb.Data_read_memory_bytes("&buf", 2)
+	if err != nil || len(blocks) != 1 || blocks[0].Begin != 0x601040 || !bytes.Equal(blocks[0].Contents, []byte{0xca, 0xfe}) {
+		t.Errorf("wrong blocks: %+v, %v", blocks, err)
+	}
+	if _, err := gdb.Data_write_memory_bytes("&buf", []byte{0}); err != nil {
+		t.Errorf("write failed: %v", err)
+	}
+	ev := <-events.C
+	if ev.MemoryAddress != 0xffffffffff600000 || ev.MemoryLen != 2 || !ev.MemoryTypeCode || ev.ThreadGroupid != "i1" {
+		t.Errorf("wrong memory-changed event: %+v", ev)
+	}
+}
@@ -2,120 +2,397 @@ package gdbmi
 
 import (
 	"bytes"
-	"strings"
-	"text/scanner"
+	"fmt"
+	"strconv"
 )
 
-type gdbStruct map[string]interface{}
+// A ParseError is returned when a line of GDB/MI output does not follow the
+// output grammar described in the GDB/MI documentation.
+type ParseError struct {
+	Input  string
+	Offset int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("gdbmi: cannot parse '%s' at offset %d: %s", e.Input, e.Offset, e.Msg)
+}
+
+// a miParser walks over one line of GDB/MI output. the grammar is:
+//
+//	output        -> ( out-of-band-record )* [ result-record ] "(gdb)"
+//	result-record -> [ token ] "^" result-class ( "," result )*
+//	async-record  -> [ token ] ( "*" | "+" | "=" ) async-class ( "," result )*
+//	stream-record -> ( "~" | "@" | "&" ) c-string
+//	result        -> variable "=" value
+//	value         -> const | tuple | list
+//	tuple         -> "{}" | "{" result ( "," result )* "}"
+//	list          -> "[]" | "[" value ( "," value )* "]" | "[" result ( "," result )* "]"
+//
+// whitespace between the syntactical elements is skipped, so pretty printed
+// output can be parsed too.
+type miParser struct {
+	input string
+	pos   int
+}
+
+func (p *miParser) errorf(format string, args ...interface{}) error {
+	return &ParseError{Input: p.input, Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *miParser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *miParser) skipSpace() {
+	for !p.eof() {
+		switch p.input[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
 
-func (s *gdbStruct) get_string(k string, def string) string {
-	v, ok := (*s)[k]
-	if ok {
-		return v.(string)
+func (p *miParser) peek() byte {
+	p.skipSpace()
+	if p.eof() {
+		return 0
 	}
-	return def
+	return p.input[p.pos]
 }
 
-func (s *gdbStruct) get_string_array(k string) []string {
-	v, ok := (*s)[k]
-	if ok {
-		string_ar := v.([]interface{})
-		var res []string
-		for _, sa := range string_ar {
-			res = append(res, sa.(string))
+func (p *miParser) expect(c byte) error {
+	if p.peek() != c {
+		if p.eof() {
+			return p.errorf("expected '%c' but input ended", c)
 		}
-		return res
+		return p.errorf("expected '%c' but found '%c'", c, p.input[p.pos])
+	}
+	p.pos++
+	return nil
+}
+
+func (p *miParser) parseToken() (int64, error) {
+	start := p.pos
+	for !p.eof() && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, nil
+	}
+	digits := p.input[start:p.pos]
+	tok, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		p.pos = start
+		return 0, p.errorf("cannot parse token %s", digits)
 	}
-	return []string{}
+	return tok, nil
 }
 
-var assignment []byte = []byte("=")
+func isVariableChar(c byte) bool {
+	switch c {
+	case '=', ',', '{', '}', '[', ']', '"', ' ', '\t', '\n', '\r':
+		return false
+	}
+	return true
+}
 
-func parseStructure(input string) gdbStruct {
-	var s scanner.Scanner
+func (p *miParser) parseVariable() (string, error) {
+	p.skipSpace()
+	start := p.pos
+	for !p.eof() && isVariableChar(p.input[p.pos]) {
+		p.pos++
+	}
+	if start == p.pos {
+		return "", p.errorf("expected a variable name")
+	}
+	return p.input[start:p.pos], nil
+}
 
-	s.Init(strings.NewReader(input))
-	return parseValue(&s).(gdbStruct)
+func (p *miParser) parseResult() (Result, error) {
+	name, err := p.parseVariable()
+	if err != nil {
+		return Result{}, err
+	}
+	if err = p.expect('='); err != nil {
+		return Result{}, err
+	}
+	val, err := p.parseValue()
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{name, val}, nil
 }
 
-func parseStructureArray(input string) []interface{} {
-	var s scanner.Scanner
+// parseResults reads a ( "," result )* sequence up to the end of the input.
+// older versions of GDB report breakpoints with multiple locations as
+// bkpt={...},{...},{...}; such unnamed tuples get the name of the result in
+// front of them.
+func (p *miParser) parseResults() (Tuple, error) {
+	result := Tuple{}
+	for p.peek() != 0 {
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		if p.peek() == '{' && len(result) > 0 {
+			val, err := p.parseTuple()
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, Result{result[len(result)-1].Name, val})
+			continue
+		}
+		r, err := p.parseResult()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
 
-	s.Init(strings.NewReader(input))
-	return parseValue(&s).([]interface{})
+func (p *miParser) parseValue() (Value, error) {
+	switch p.peek() {
+	case '"':
+		c, err := p.parseCString()
+		if err != nil {
+			return nil, err
+		}
+		return Const(c), nil
+	case '{':
+		return p.parseTuple()
+	case '[':
+		return p.parseList()
+	case 0:
+		return nil, p.errorf("expected a value but input ended")
+	}
+	return nil, p.errorf("expected a value but found '%c'", p.input[p.pos])
 }
 
-func parseStruct(s *scanner.Scanner) gdbStruct {
-	result := make(map[string]interface{})
-struct_loop:
+func (p *miParser) parseTuple() (Tuple, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	result := Tuple{}
+	if p.peek() == '}' {
+		p.pos++
+		return result, nil
+	}
 	for {
-		s.Scan()
-		key := s.TokenText()
-		s.Scan()
-		assign := s.TokenText()
-		for !bytes.Equal([]byte(assign), assignment) {
-			key = key + assign
-			s.Scan()
-			assign = s.TokenText()
-		}
-		val := parseValue(s)
-		result[key] = val
-		s.Scan()
-		delim := s.TokenText()
-		switch delim {
-		case "}":
-			break struct_loop
-		}
-	}
-	return result
-}
-
-func createAnonymousStruct(key string, val interface{}) gdbStruct {
-	result := make(map[string]interface{})
-	result[key] = val
-	return result
-}
-
-func parseValue(s *scanner.Scanner) interface{} {
-	s.Scan()
-	tt := s.TokenText()
-	switch tt {
-	case "{":
-		return parseStruct(s)
-	case "[":
-		return parseArray(s)
-	case "]":
-		return nil
-	case "=":
-		return "="
-	case ",":
-		return parseValue(s)
-	default:
-		btt := []byte(tt)
-		if btt[0] == '"' {
-			return string(btt[1 : len(tt)-1])
-		}
-		return tt
-	}
-}
-
-func parseArray(s *scanner.Scanner) []interface{} {
-	var result []interface{}
-	for val := parseValue(s); val != nil; {
-		nextval := parseValue(s)
-		sval, ok := nextval.(string)
-		if ok {
-			if equals(sval, "=") {
-				// we have a [key=val,key=val,key=val] list --> create struct for each entry
-				nextval := parseValue(s)
-				keyval := val.(string)
-				result = append(result, createAnonymousStruct(keyval, nextval))
-				val = parseValue(s)
-				continue
-			}
+		r, err := p.parseResult()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return result, nil
+		default:
+			return nil, p.errorf("expected ',' or '}' in tuple")
+		}
+	}
+}
+
+// parseList returns the elements of a list. the elements of a result list like
+// [frame={...},frame={...}] are returned as tuples with a single result.
+func (p *miParser) parseList() (List, error) {
+	if err := p.expect('['); err != nil {
+		return nil, err
+	}
+	result := List{}
+	if p.peek() == ']' {
+		p.pos++
+		return result, nil
+	}
+	for {
+		var val Value
+		var err error
+		switch p.peek() {
+		case '"', '{', '[':
+			val, err = p.parseValue()
+		default:
+			var r Result
+			r, err = p.parseResult()
+			val = Tuple{r}
+		}
+		if err != nil {
+			return nil, err
 		}
 		result = append(result, val)
-		val = nextval
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return result, nil
+		default:
+			return nil, p.errorf("expected ',' or ']' in list")
+		}
+	}
+}
+
+// parseCString reads a quoted C string and decodes its escape sequences. GDB
+// escapes non printable characters (and sometimes the bytes of UTF-8 sequences)
+// as octal numbers, so the string is decoded bytewise.
+func (p *miParser) parseCString() (string, error) {
+	if err := p.expect('"'); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	for !p.eof() {
+		c := p.input[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return buf.String(), nil
+		case '\\':
+			if p.eof() {
+				return "", p.errorf("unterminated escape sequence")
+			}
+			c = p.input[p.pos]
+			p.pos++
+			switch c {
+			case 'n':
+				buf.WriteByte('\n')
+			case 't':
+				buf.WriteByte('\t')
+			case 'r':
+				buf.WriteByte('\r')
+			case 'a':
+				buf.WriteByte('\a')
+			case 'b':
+				buf.WriteByte('\b')
+			case 'f':
+				buf.WriteByte('\f')
+			case 'v':
+				buf.WriteByte('\v')
+			case 'e':
+				buf.WriteByte(0x1b)
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				v := int(c - '0')
+				for i := 0; i < 2 && !p.eof() && p.input[p.pos] >= '0' && p.input[p.pos] <= '7'; i++ {
+					v = v*8 + int(p.input[p.pos]-'0')
+					p.pos++
+				}
+				if v > 0xff {
+					return "", p.errorf("octal escape out of range")
+				}
+				buf.WriteByte(byte(v))
+			case 'x':
+				start := p.pos
+				for p.pos-start < 2 && !p.eof() && isHexDigit(p.input[p.pos]) {
+					p.pos++
+				}
+				if start == p.pos {
+					return "", p.errorf("invalid hex escape")
+				}
+				v, _ := strconv.ParseUint(p.input[start:p.pos], 16, 8)
+				buf.WriteByte(byte(v))
+			default:
+				// \" \\ \' \? and everything unknown stand for themselves
+				buf.WriteByte(c)
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// parseRecord parses one line of GDB/MI output. The prompt line "(gdb)" results
+// in a nil response.
+func parseRecord(line string) (gdb_response, error) {
+	p := &miParser{input: line}
+	p.skipSpace()
+	if p.input[p.pos:] == "(gdb)" {
+		return nil, nil
+	}
+	token, err := p.parseToken()
+	if err != nil {
+		return nil, err
+	}
+	if p.eof() {
+		return nil, p.errorf("missing record type")
+	}
+	kind := p.input[p.pos]
+	p.pos++
+	switch kind {
+	case '^', '*', '+', '=':
+		rt := gdb_response_type{token: token, line: p.input[p.pos:]}
+		class, err := p.parseVariable()
+		if err != nil {
+			return nil, err
+		}
+		raw := ""
+		if rest := p.input[p.pos:]; len(rest) > 0 {
+			raw = rest[1:]
+		}
+		results, err := p.parseResults()
+		if err != nil {
+			return nil, err
+		}
+		if kind == '^' {
+			return &gdb_result{gdb_response_type: rt, class: class, results: results, raw: raw}, nil
+		}
+		return &gdb_async{rt, kind, class, results}, nil
+	case '~', '@', '&':
+		if token != 0 {
+			return nil, p.errorf("stream records cannot have a token")
+		}
+		rt := gdb_response_type{line: p.input[p.pos:]}
+		text, err := p.parseCString()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != 0 {
+			return nil, p.errorf("unexpected data after stream record")
+		}
+		switch kind {
+		case '~':
+			return &gdb_console_output{rt, text}, nil
+		case '@':
+			return &gdb_target_output{rt, text}, nil
+		}
+		return &gdb_log_output{rt, text}, nil
+	}
+	p.pos--
+	return nil, p.errorf("unknown record type '%c'", kind)
+}
+
+// parseStructure parses a tuple like {key="value",...}
+func parseStructure(input string) (Tuple, error) {
+	p := &miParser{input: input}
+	res, err := p.parseTuple()
+	if err == nil && p.peek() != 0 {
+		err = p.errorf("unexpected data after tuple")
+	}
+	return res, err
+}
+
+// parseStructureArray parses a list like [value,...] or [key=value,...]
+func parseStructureArray(input string) (List, error) {
+	p := &miParser{input: input}
+	res, err := p.parseList()
+	if err == nil && p.peek() != 0 {
+		err = p.errorf("unexpected data after list")
+	}
+	return res, err
+}
+
+// parseResultList parses the results of a record like key="value",key={...}
+func parseResultList(input string) (Tuple, error) {
+	if len(input) == 0 {
+		return Tuple{}, nil
 	}
-	return result
+	p := &miParser{input: "," + input}
+	return p.parseResults()
 }
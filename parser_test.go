@@ -2,6 +2,7 @@ package gdbmi
 
 import (
 	"fmt"
+	"testing"
 )
 
 var (
@@ -9,13 +10,118 @@ var (
 	msg2 = `reason="breakpoint-hit",disp="keep",bkptno="1",frame={addr="0x0000000000400d10",func="main.sub",args=[{name="s2",value="..."},{name="s1",value="..."},{name="~anon2",value="..."}],file="/home/usc/workspaces/gdbmi/src/github.com/ulrichSchreiner/gdbmi/cmd/main.go",fullname="/home/usc/workspaces/gdbmi/src/github.com/ulrichSchreiner/gdbmi/cmd/main.go",line="14"},thread-id="1",stopped-threads="all",core="0"`
 )
 
-func ExampleStructureParser() {
-	g := parseStructure(msg)
-	tg := g["thread-groups"].([]interface{})
-	fmt.Printf("number=%s,type=%s,disp=%s,enabled=%s,addr=%s,func=%s,file=%s,fullname=%s,times=%s,original-location=%s\n", g["number"], g["type"], g["disp"], g["enabled"], g["addr"], g["func"], g["file"], g["fullname"], g["times"], g["original-location"])
+func Example_structureParser() {
+	g, _ := parseStructure(msg)
+	tg := g.Get("thread-groups").(List)
+	fmt.Printf("number=%s,type=%s,disp=%s,enabled=%s,addr=%s,func=%s,file=%s,fullname=%s,times=%s,original-location=%s\n", g.Get("number"), g.Get("type"), g.Get("disp"), g.Get("enabled"), g.Get("addr"), g.Get("func"), g.Get("file"), g.Get("fullname"), g.Get("times"), g.Get("original-location"))
 	for i, t := range tg {
 		fmt.Printf("%d:%s\n", i, t)
 	}
 	// Output: number=1,type=breakpoint,disp=keep,enabled=y,addr=0x00000000004214a0,func=main,file=/usr/local/go/src/pkg/runtime/rt0_linux_amd64.s,fullname=/usr/local/go/src/pkg/runtime/rt0_linux_amd64.s,times=1,original-location=main
 	// 0:i1
 }
+
+func TestParseRecord(t *testing.T) {
+	rsp, err := parseRecord(`42^done,stack=[frame={level="0",func="main"},frame={level="1",func="sub"}]`)
+	if err != nil {
+		t.Fatalf("cannot parse result record: %s", err)
+	}
+	res, ok := rsp.(*gdb_result)
+	if !ok || res.Token() != 42 || res.class != "done" {
+		t.Fatalf("wrong result record: %+v", rsp)
+	}
+	stack := res.results.Get("stack")
+	if stack.Len() != 2 {
+		t.Fatalf("result list should have 2 entries: %s", stack)
+	}
+	if f := stack.Index(1).Get("frame").Get("func").String(); f != "sub" {
+		t.Errorf("wrong function in second frame: %s", f)
+	}
+
+	rsp, err = parseRecord("*stopped," + msg2)
+	if err != nil {
+		t.Fatalf("cannot parse async record: %s", err)
+	}
+	async := rsp.(*gdb_async)
+	if async.kind != '*' || async.class != "stopped" {
+		t.Errorf("wrong async record: %+v", async)
+	}
+	if st := valueStrings(async.results.Get("stopped-threads")); len(st) != 1 || st[0] != "all" {
+		t.Errorf("wrong stopped-threads: %v", st)
+	}
+
+	rsp, err = parseRecord(`~"a \"quoted\"\tline\n\303\244\\"`)
+	if err != nil {
+		t.Fatalf("cannot parse stream record: %s", err)
+	}
+	if txt := rsp.(*gdb_console_output).text; txt != "a \"quoted\"\tline\nä\\" {
+		t.Errorf("wrong unescaped console output: %q", txt)
+	}
+
+	if rsp, err = parseRecord("(gdb)"); rsp != nil || err != nil {
+		t.Errorf("prompt should be ignored: %v, %v", rsp, err)
+	}
+
+	rsp, err = parseRecord(`^done,bkpt={number="1",addr="<MULTIPLE>"},{number="1.1",addr="0x1"},{number="1.2",addr="0x2"}`)
+	if err != nil {
+		t.Fatalf("cannot parse multiple location breakpoint: %s", err)
+	}
+	if locs := rsp.(*gdb_result).results.GetAll("bkpt"); len(locs) != 3 || locs[2].Get("number").String() != "1.2" {
+		t.Errorf("wrong breakpoint locations: %v", locs)
+	}
+}
+
+func TestParseRecordErrors(t *testing.T) {
+	broken := []string{
+		``,
+		`^`,
+		`^done,`,
+		`^done,x`,
+		`^done,x=`,
+		`^done,x="unterminated`,
+		`^done,x={a="1"`,
+		`^done,x=[a="1",`,
+		`^done,x="1"}`,
+		`*stopped,frame={a="1",b}`,
+		`~"a" trailing`,
+		`12~"token"`,
+		`#unknown`,
+		`"\`,
+	}
+	for _, b := range broken {
+		_, err := parseRecord(b)
+		if err == nil {
+			t.Errorf("'%s' should not be parseable", b)
+			continue
+		}
+		if _, ok := err.(*ParseError); !ok {
+			t.Errorf("'%s' should return a ParseError: %T", b, err)
+		}
+	}
+}
+
+func TestParseOutputLine(t *testing.T) {
+	rsp := parseOutputLine(`7^done,value="unterminated`)
+	res, ok := rsp.(*gdb_result)
+	if !ok || res.Token() != 7 || res.err == nil {
+		t.Errorf("broken result record should carry its error: %+v", rsp)
+	}
+	if _, err := createResult(res); err == nil {
+		t.Errorf("broken result record should not create a result")
+	}
+	rsp = parseOutputLine("Hello World")
+	if out, ok := rsp.(*gdb_target_output); !ok || out.text != "Hello World" {
+		t.Errorf("unknown output should be target output: %+v", rsp)
+	}
+}
+
+func ExampleValue() {
+	res, _ := parseResultList(`frame={level="0",addr="0x0000000000400d10",func="main.sub",line="14"},thread-groups=["i1"]`)
+	line, _ := res.Get("frame").Get("line").Int()
+	addr, _ := res.Get("frame").Get("addr").Uint64()
+	_, err := res.Get("frame").Get("nothing").Int()
+	fmt.Printf("line=%d,addr=%#x,group=%s,err=%s\n", line, addr, res.Get("thread-groups").Index(0), err)
+	fmt.Println(res.Get("frame"))
+	// Output: line=14,addr=0x400d10,group=i1,err=value does not exist
+	// {level="0",addr="0x0000000000400d10",func="main.sub",line="14"}
+}
@@ -0,0 +1,153 @@
+package gdbmi
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// Recording describes the process record of a thread group. Method is "full"
+// or "btrace", Format is the format of btrace ("bts" or "pt").
+type Recording struct {
+	ThreadGroup string `json:"threadGroup"`
+	Method      string `json:"method"`
+	Format      string `json:"format"`
+}
+
+// recordStates tracks the recordings from the record-started and
+// record-stopped notifications.
+type recordStates struct {
+	sync.Mutex
+	recordings map[string]Recording
+}
+
+func (rs *recordStates) update(rt *gdb_async) {
+	gid := rt.results.Get("thread-group").String()
+	rs.Lock()
+	defer rs.Unlock()
+	switch rt.class {
+	case "record-started":
+		if rs.recordings == nil {
+			rs.recordings = make(map[string]Recording)
+		}
+		rs.recordings[gid] = Recording{gid, rt.results.Get("method").String(), rt.results.Get("format").String()}
+	case "record-stopped":
+		delete(rs.recordings, gid)
+	case "thread-group-exited":
+		delete(rs.recordings, gid)
+	}
+}
+
+// Recordings returns the active recordings of all thread groups.
+func (gdb *GDB) Recordings() []Recording {
+	gdb.records.Lock()
+	defer gdb.records.Unlock()
+	var res []Recording
+	for _, r := range gdb.records.recordings {
+		res = append(res, r)
+	}
+	return res
+}
+
+// Recording returns the recording of the thread group, if it is recorded.
+func (gdb *GDB) Recording(threadgroup string) (Recording, bool) {
+	gdb.records.Lock()
+	defer gdb.records.Unlock()
+	r, ok := gdb.records.recordings[threadgroup]
+	return r, ok
+}
+
+// Record_start starts recording the execution of the running program, so it
+// can be executed in reverse. The method is "full" (the default if empty),
+// "btrace", "btrace bts" or "btrace pt".
+func (gdb *GDB) Record_start(method string) (*GDBResult, error) {
+	return gdb.Record_startContext(context.Background(), method)
+}
+
+func (gdb *GDB) Record_startContext(ctx context.Context, method string) (*GDBResult, error) {
+	if len(method) == 0 {
+		method = "full"
+	}
+	return gdb.Console(ctx, "record "+method)
+}
+
+// Record_stop stops recording and discards the recorded execution.
+func (gdb *GDB) Record_stop() (*GDBResult, error) {
+	return gdb.Record_stopContext(context.Background())
+}
+
+func (gdb *GDB) Record_stopContext(ctx context.Context) (*GDBResult, error) {
+	return gdb.Console(ctx, "record stop")
+}
+
+// Record_goto replays the recorded execution to "begin", "end" or the given
+// instruction number.
+func (gdb *GDB) Record_goto(target string) (*GDBResult, error) {
+	return gdb.Record_gotoContext(context.Background(), target)
+}
+
+func (gdb *GDB) Record_gotoContext(ctx context.Context, target string) (*GDBResult, error) {
+	return gdb.Console(ctx, "record goto "+target)
+}
+
+// Record_bookmark saves the current position in the recorded execution and
+// returns the number of the bookmark.
+func (gdb *GDB) Record_bookmark() (int, error) {
+	return gdb.Record_bookmarkContext(context.Background())
+}
+
+func (gdb *GDB) Record_bookmarkContext(ctx context.Context) (int, error) {
+	res, err := gdb.Console(ctx, "bookmark")
+	if err != nil {
+		return 0, err
+	}
+	for _, l := range res.Console {
+		var n int
+		if _, err := fmt.Sscanf(l, "Saved bookmark %d", &n); err == nil {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("gdbmi: no bookmark number in %q", res.Console)
+}
+
+// Record_goto_bookmark replays the recorded execution to the bookmark.
+func (gdb *GDB) Record_goto_bookmark(bookmark int) (*GDBResult, error) {
+	return gdb.Record_goto_bookmarkContext(context.Background(), bookmark)
+}
+
+func (gdb *GDB) Record_goto_bookmarkContext(ctx context.Context, bookmark int) (*GDBResult, error) {
+	return gdb.Console(ctx, "goto-bookmark "+strconv.Itoa(bookmark))
+}
+
+// ReverseContinueAndWait runs the recorded program backwards and waits until
+// it stops.
+func (gdb *GDB) ReverseContinueAndWait(ctx context.Context, opts ...Option) (*GDBEvent, error) {
+	return gdb.resumeAndWait(ctx, "exec-continue", opts, func() (*GDBResult, error) {
+		return gdb.Exec_continueContext(ctx, false, true, "", opts...)
+	})
+}
+
+// ReverseNextAndWait steps backwards over the previous line and waits until
+// the program stops.
+func (gdb *GDB) ReverseNextAndWait(ctx context.Context, opts ...Option) (*GDBEvent, error) {
+	return gdb.resumeAndWait(ctx, "exec-next", opts, func() (*GDBResult, error) {
+		return gdb.Exec_nextContext(ctx, true, opts...)
+	})
+}
+
+// ReverseStepAndWait steps backwards into the previous line and waits until
+// the program stops.
+func (gdb *GDB) ReverseStepAndWait(ctx context.Context, opts ...Option) (*GDBEvent, error) {
+	return gdb.resumeAndWait(ctx, "exec-step", opts, func() (*GDBResult, error) {
+		return gdb.Exec_stepContext(ctx, true, opts...)
+	})
+}
+
+// ReverseFinishAndWait runs backwards to the call of the current function and
+// waits until the program stops.
+func (gdb *GDB) ReverseFinishAndWait(ctx context.Context, opts ...Option) (*GDBEvent, error) {
+	return gdb.resumeAndWait(ctx, "exec-finish", opts, func() (*GDBResult, error) {
+		return gdb.Exec_finishContext(ctx, true, opts...)
+	})
+}
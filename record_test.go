@@ -0,0 +1,42 @@
+package gdbmi
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRecord(t *testing.T) {
+	gdb := fakeSession(t,
+		expect(`-interpreter-exec console "record full"`, `=record-started,thread-group="i1",method="full"`, `1^done`),
+		expect(`-interpreter-exec console bookmark`, `~"Saved bookmark 1 at 0x400531\n"`, `1^done`),
+		expect(`-exec-next --reverse`,
+			`1^running`,
+			`*running,thread-id="all"`,
+			`*stopped,reason="no-history",frame={addr="0x1",func="main",args=[]},thread-id="1"`),
+		expect(`-interpreter-exec console "goto-bookmark 1"`, `1^done`),
+		expect(`-interpreter-exec console "record stop"`, `=record-stopped,thread-group="i1"`, `1^done`),
+	)
+	if _, err := gdb.Record_start(""); err != nil {
+		t.Fatalf("record failed: %s", err)
+	}
+	if r, ok := gdb.Recording("i1"); !ok || r.Method != "full" {
+		t.Errorf("i1 should be recorded: %+v", r)
+	}
+	bm, err := gdb.Record_bookmark()
+	if err != nil || bm != 1 {
+		t.Fatalf("bookmark failed: %d, %s", bm, err)
+	}
+	ev, err := gdb.ReverseNextAndWait(context.Background())
+	if err != nil || ev.StopReason != Async_stopped_no_history {
+		t.Errorf("wrong reverse stop: %+v, %s", ev, err)
+	}
+	if _, err := gdb.Record_goto_bookmark(bm); err != nil {
+		t.Fatalf("goto bookmark failed: %s", err)
+	}
+	if _, err := gdb.Record_stop(); err != nil {
+		t.Fatalf("record stop failed: %s", err)
+	}
+	if r := gdb.Recordings(); len(r) != 0 {
+		t.Errorf("no recording should be active: %+v", r)
+	}
+}
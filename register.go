@@ -0,0 +1,133 @@
+package gdbmi
+
+import (
+	"context"
+	"strconv"
+)
+
+// A RegisterFormat is the format in which GDB lists the values of registers.
+type RegisterFormat string
+
+const (
+	RegisterFormat_hex     RegisterFormat = "x"
+	RegisterFormat_octal   RegisterFormat = "o"
+	RegisterFormat_binary  RegisterFormat = "t"
+	RegisterFormat_decimal RegisterFormat = "d"
+	RegisterFormat_raw     RegisterFormat = "r"
+	RegisterFormat_natural RegisterFormat = "N"
+)
+
+// A RegisterValue is the value of the register with the number in a
+// RegisterFormat.
+type RegisterValue struct {
+	Number int    `json:"number" mi:"number"`
+	Value  string `json:"value" mi:"value"`
+}
+
+// Data_list_register_names returns the names of the given registers or of all
+// registers. The index of a name is the number of the register, registers
+// without a name do not exist.
+func (gdb *GDB) Data_list_register_names(regnos ...int) ([]string, error) {
+	return gdb.Data_list_register_namesContext(context.Background(), regnos...)
+}
+
+func (gdb *GDB) Data_list_register_namesContext(ctx context.Context, regnos ...int) ([]string, error) {
+	c := newCommandContext(ctx, "data-list-register-names")
+	for _, r := range regnos {
+		c.add_param(strconv.Itoa(r))
+	}
+	res, err := gdb.send(c)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	err = Unmarshal(res.Values.Get("register-names"), &names)
+	return names, err
+}
+
+// Data_list_register_values returns the values of the given registers or of all
+// registers in the format. With ThreadOption and FrameOption the registers of
+// another thread or frame are listed.
+func (gdb *GDB) Data_list_register_values(format RegisterFormat, regnos []int, opts ...Option) ([]RegisterValue, error) {
+	return gdb.Data_list_register_valuesContext(context.Background(), format, regnos, opts...)
+}
+
+func (gdb *GDB) Data_list_register_valuesContext(ctx context.Context, format RegisterFormat, regnos []int, opts ...Option) ([]RegisterValue, error) {
+	c := newCommandContext(ctx, "data-list-register-values").add_options(opts).add_option("--skip-unavailable")
+	c.add_param(string(format))
+	for _, r := range regnos {
+		c.add_param(strconv.Itoa(r))
+	}
+	res, err := gdb.send(c)
+	if err != nil {
+		return nil, err
+	}
+	var values []RegisterValue
+	err = Unmarshal(res.Values.Get("register-values"), &values)
+	return values, err
+}
+
+// Data_list_changed_registers returns the numbers of the registers which
+// changed since the previous call of the command. GDB keeps only the registers
+// of this previous call, whatever thread or frame they belonged to, so the
+// first call returns all registers. With ThreadOption and FrameOption the
+// registers of another thread or frame are compared.
+func (gdb *GDB) Data_list_changed_registers(opts ...Option) ([]int, error) {
+	return gdb.Data_list_changed_registersContext(context.Background(), opts...)
+}
+
+func (gdb *GDB) Data_list_changed_registersContext(ctx context.Context, opts ...Option) ([]int, error) {
+	res, err := gdb.send(newCommandContext(ctx, "data-list-changed-registers").add_options(opts))
+	if err != nil {
+		return nil, err
+	}
+	var regnos []int
+	err = Unmarshal(res.Values.Get("changed-registers"), &regnos)
+	return regnos, err
+}
+
+// Registers returns the values of all available registers by name.
+func (gdb *GDB) Registers(ctx context.Context, format RegisterFormat, opts ...Option) (map[string]string, error) {
+	names, err := gdb.Data_list_register_namesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	values, err := gdb.Data_list_register_valuesContext(ctx, format, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	regs := make(map[string]string, len(values))
+	for _, v := range values {
+		if v.Number < len(names) && len(names[v.Number]) > 0 {
+			regs[names[v.Number]] = v.Value
+		}
+	}
+	return regs, nil
+}
+
+// ChangedRegisters returns the names of the registers which changed since the
+// previous call, see Data_list_changed_registers. To get the registers which a
+// step changed, call it before and after the step.
+func (gdb *GDB) ChangedRegisters(ctx context.Context, opts ...Option) ([]string, error) {
+	regnos, err := gdb.Data_list_changed_registersContext(ctx, opts...)
+	if err != nil || len(regnos) == 0 {
+		return nil, err
+	}
+	names, err := gdb.Data_list_register_namesContext(ctx, regnos...)
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for _, n := range names {
+		if len(n) > 0 {
+			changed = append(changed, n)
+		}
+	}
+	return changed, nil
+}
+
+// WriteRegister assigns the value (an expression) to the register with the
+// name, like "rax", and returns the new value.
+func (gdb *GDB) WriteRegister(ctx context.Context, name string, value string, opts ...Option) (string, error) {
+	return gdb.Data_evaluate_expressionContext(ctx, "$"+name+"="+value, opts...)
+}
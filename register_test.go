@@ -0,0 +1,46 @@
+package gdbmi
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRegisters(t *testing.T) {
+	gdb := fakeSession(t,
+		expect(`-data-list-register-names`, `1^done,register-names=["rax","rbx","rcx","",""]`),
+		expect(`-data-list-register-values --thread 1 --skip-unavailable x`, `1^done,register-values=[{number="0",value="0x1c"},{number="1",value="0x0"},{number="2",value="0x7fffffffe2e8"}]`),
+		// the first call reports all registers
+		expect(`-data-list-changed-registers --thread 1`, `1^done,changed-registers=["0","1","2"]`),
+		expect(`-data-list-register-names 0 1 2`, `1^done,register-names=["rax","rbx","rcx"]`),
+		expect(`-data-list-changed-registers --thread 1`, `1^done,changed-registers=["0","2"]`),
+		expect(`-data-list-register-names 0 2`, `1^done,register-names=["rax","rcx"]`),
+		expect(`-data-list-changed-registers`, `1^done,changed-registers=[]`),
+		expect(`-data-list-register-values --skip-unavailable d 2`, `1^done,register-values=[{number="2",value="16"}]`),
+		expect(`-data-evaluate-expression $rcx=0x10`, `1^done,value="16"`),
+	)
+	ctx := context.Background()
+	regs, err := gdb.Registers(ctx, RegisterFormat_hex, ThreadOption("1"))
+	if err != nil {
+		t.Fatalf("registers failed: %s", err)
+	}
+	if len(regs) != 3 || regs["rax"] != "0x1c" || regs["rcx"] != "0x7fffffffe2e8" {
+		t.Errorf("wrong registers: %v", regs)
+	}
+	if changed, err := gdb.ChangedRegisters(ctx, ThreadOption("1")); err != nil || len(changed) != 3 {
+		t.Errorf("wrong changed registers: %v, %v", changed, err)
+	}
+	changed, err := gdb.ChangedRegisters(ctx, ThreadOption("1"))
+	if err != nil || len(changed) != 2 || changed[1] != "rcx" {
+		t.Errorf("wrong changed registers: %v, %v", changed, err)
+	}
+	if regnos, err := gdb.Data_list_changed_registers(); err != nil || len(regnos) != 0 {
+		t.Errorf("no register should have changed: %v, %v", regnos, err)
+	}
+	values, err := gdb.Data_list_register_values(RegisterFormat_decimal, []int{2})
+	if err != nil || len(values) != 1 || values[0] != (RegisterValue{2, "16"}) {
+		t.Errorf("wrong values: %+v, %v", values, err)
+	}
+	if v, err := gdb.WriteRegister(ctx, "rcx", "0x10"); err != nil || v != "16" {
+		t.Errorf("write failed: %s, %v", v, err)
+	}
+}
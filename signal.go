@@ -0,0 +1,164 @@
+package gdbmi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SignalHandling is the disposition of a signal in GDB: if the program stops
+// when it receives the signal, if GDB prints a message and if the signal is
+// passed to the program.
+type SignalHandling struct {
+	Signal      string `json:"signal"`
+	Stop        bool   `json:"stop"`
+	Print       bool   `json:"print"`
+	Pass        bool   `json:"pass"`
+	Description string `json:"description"`
+}
+
+// Siginfo contains the fields of $_siginfo (on Linux) for the last signal of the
+// current thread. Addr is the fault address of SIGSEGV, SIGBUS, SIGILL and
+// SIGFPE.
+type Siginfo struct {
+	Signo int    `json:"signo"`
+	Errno int    `json:"errno"`
+	Code  int    `json:"code"`
+	Addr  uint64 `json:"addr"`
+}
+
+func onOff(flg bool, on, off string) string {
+	if flg {
+		return on
+	}
+	return off
+}
+
+// Handle_signal sets the disposition of the signal (like "SIGUSR1" or "all").
+// Stopping implies printing, so Print is ignored if Stop is set.
+func (gdb *GDB) Handle_signal(h SignalHandling) (*GDBResult, error) {
+	return gdb.Handle_signalContext(context.Background(), h)
+}
+
+func (gdb *GDB) Handle_signalContext(ctx context.Context, h SignalHandling) (*GDBResult, error) {
+	return gdb.Console(ctx, fmt.Sprintf("handle %s %s %s %s", h.Signal,
+		onOff(h.Stop, "stop", "nostop"),
+		onOff(h.Print || h.Stop, "print", "noprint"),
+		onOff(h.Pass, "pass", "nopass")))
+}
+
+// Signal_handling returns the disposition of the given signals or of all
+// signals.
+func (gdb *GDB) Signal_handling(signals ...string) ([]SignalHandling, error) {
+	return gdb.Signal_handlingContext(context.Background(), signals...)
+}
+
+func (gdb *GDB) Signal_handlingContext(ctx context.Context, signals ...string) ([]SignalHandling, error) {
+	cmd := "info signals"
+	if len(signals) > 0 {
+		cmd = cmd + " " + strings.Join(signals, " ")
+	}
+	res, err := gdb.Console(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return parseSignalTable(strings.Join(res.Console, "")), nil
+}
+
+// parseSignalTable parses the table of "info signals"
+//
+//	Signal        Stop	Print	Pass to program	Description
+//	SIGHUP        Yes	Yes	Yes		Hangup
+func parseSignalTable(table string) []SignalHandling {
+	var res []SignalHandling
+	for _, l := range strings.Split(table, "\n") {
+		f := strings.Fields(l)
+		if len(f) < 4 {
+			continue
+		}
+		var flags [3]bool
+		valid := true
+		for i := range flags {
+			switch f[i+1] {
+			case "Yes":
+				flags[i] = true
+			case "No":
+			default:
+				valid = false
+			}
+		}
+		if valid {
+			res = append(res, SignalHandling{f[0], flags[0], flags[1], flags[2], strings.Join(f[4:], " ")})
+		}
+	}
+	return res
+}
+
+// Exec_signal resumes the program and delivers the signal to it. With "0" the
+// program continues without a signal, even if it was stopped by one.
+func (gdb *GDB) Exec_signal(signal string, opts ...Option) (*GDBResult, error) {
+	return gdb.Exec_signalContext(context.Background(), signal, opts...)
+}
+
+func (gdb *GDB) Exec_signalContext(ctx context.Context, signal string, opts ...Option) (*GDBResult, error) {
+	c := newCommandContext(ctx, "interpreter-exec").add_options(opts).add_param("console")
+	return gdb.send(c.add_quoted_param("signal " + signal))
+}
+
+// SignalAndWait resumes the program with the signal and waits until it stops.
+func (gdb *GDB) SignalAndWait(ctx context.Context, signal string, opts ...Option) (*GDBEvent, error) {
+	return gdb.resumeAndWait(ctx, "signal", opts, func() (*GDBResult, error) {
+		return gdb.Exec_signalContext(ctx, signal, opts...)
+	})
+}
+
+func (gdb *GDB) evaluate_int(ctx context.Context, expr string, opts []Option) (int64, error) {
+	val, err := gdb.Data_evaluate_expressionContext(ctx, expr, opts...)
+	if err != nil {
+		return 0, err
+	}
+	return (&Evaluation{Expression: expr, Value: val}).Int()
+}
+
+// Siginfo returns the information of the last signal of the current thread or
+// of the thread given with a ThreadOption.
+func (gdb *GDB) Siginfo(ctx context.Context, opts ...Option) (*Siginfo, error) {
+	var si Siginfo
+	fields := []struct {
+		expr string
+		val  *int
+	}{
+		{"$_siginfo.si_signo", &si.Signo},
+		{"$_siginfo.si_errno", &si.Errno},
+		{"$_siginfo.si_code", &si.Code},
+	}
+	for _, f := range fields {
+		n, err := gdb.evaluate_int(ctx, f.expr, opts)
+		if err != nil {
+			return nil, err
+		}
+		*f.val = int(n)
+	}
+	switch si.Signo {
+	case 4, 7, 8, 11: // SIGILL, SIGBUS, SIGFPE, SIGSEGV
+		addr, err := gdb.evaluate_int(ctx, "$_siginfo._sifields._sigfault.si_addr", opts)
+		if err != nil {
+			return nil, err
+		}
+		si.Addr = uint64(addr)
+	}
+	return &si, nil
+}
+
+// StopSiginfo returns the information of the signal which stopped the thread of
+// the stopped event. Other stops have no Siginfo, nil is returned for them.
+func (gdb *GDB) StopSiginfo(ctx context.Context, ev *GDBEvent) (*Siginfo, error) {
+	if ev.Type != Async_stopped || ev.StopReason != Async_stopped_signal_received {
+		return nil, nil
+	}
+	var opts []Option
+	if len(ev.ThreadId) > 0 {
+		opts = append(opts, ThreadOption(ev.ThreadId))
+	}
+	return gdb.Siginfo(ctx, opts...)
+}
@@ -0,0 +1,75 @@
+package gdbmi
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSignals(t *testing.T) {
+	gdb := fakeSession(t,
+		expect(`-interpreter-exec console "handle SIGUSR1 nostop noprint pass"`, `1^done`),
+		expect(`-interpreter-exec console "info signals SIGHUP SIGSEGV"`,
+			`~"Signal        Stop\tPrint\tPass to program\tDescription\n"`,
+			`~"SIGHUP        Yes\tYes\tYes\t\tHangup\n"`,
+			`~"SIGSEGV       Yes\tYes\tYes\t\tSegmentation fault\n"`,
+			`1^done`),
+		expect(`-interpreter-exec console "signal SIGUSR2"`,
+			`1^running`,
+			`*stopped,reason="signal-received",signal-name="SIGSEGV",signal-meaning="Segmentation fault",thread-id="1",stopped-threads="all"`),
+		expect(`-data-evaluate-expression --thread 1 $_siginfo.si_signo`, `1^done,value="11"`),
+		expect(`-data-evaluate-expression --thread 1 $_siginfo.si_errno`, `1^done,value="0"`),
+		expect(`-data-evaluate-expression --thread 1 $_siginfo.si_code`, `1^done,value="1"`),
+		expect(`-data-evaluate-expression --thread 1 $_siginfo._sifields._sigfault.si_addr`, `1^done,value="(void *) 0xdeadbeef"`),
+		expect(`-interpreter-exec --thread 1 console "signal 0"`,
+			`1^running`,
+			`*stopped,reason="signal-received",signal-name="SIGINT",thread-id="1",stopped-threads="all"`),
+		expect(`-data-evaluate-expression --thread 1 $_siginfo.si_signo`, `1^error,msg="Unable to read siginfo"`),
+		expect("-exec-continue",
+			`1^running`,
+			`*stopped,reason="signal-received",signal-name="SIGUSR1",thread-id="1",stopped-threads="all"`),
+		expect(`-data-evaluate-expression --thread 1 $_siginfo.si_signo`, `1^done,value="10"`),
+		expect(`-data-evaluate-expression --thread 1 $_siginfo.si_errno`, `1^done,value="0"`),
+		expect(`-data-evaluate-expression --thread 1 $_siginfo.si_code`, `1^done,value="0"`),
+	)
+	ctx := context.Background()
+	if _, err := gdb.Handle_signal(SignalHandling{Signal: "SIGUSR1", Pass: true}); err != nil {
+		t.Fatalf("handle failed: %s", err)
+	}
+	table, err := gdb.Signal_handling("SIGHUP", "SIGSEGV")
+	if err != nil {
+		t.Fatalf("info signals failed: %s", err)
+	}
+	expected := []SignalHandling{{"SIGHUP", true, true, true, "Hangup"}, {"SIGSEGV", true, true, true, "Segmentation fault"}}
+	if len(table) != 2 || table[0] != expected[0] || table[1] != expected[1] {
+		t.Errorf("wrong signal table: %+v", table)
+	}
+	ev, err := gdb.SignalAndWait(ctx, "SIGUSR2")
+	if err != nil || ev.SignalName != "SIGSEGV" {
+		t.Fatalf("wrong signal stop: %+v, %v", ev, err)
+	}
+	// the siginfo is only read on demand
+	si, err := gdb.StopSiginfo(ctx, ev)
+	if err != nil || si == nil || *si != (Siginfo{11, 0, 1, 0xdeadbeef}) {
+		t.Errorf("wrong siginfo: %+v, %v", si, err)
+	}
+	ev, err = gdb.SignalAndWait(ctx, "0", ThreadOption("1"))
+	if err != nil || ev.SignalName != "SIGINT" {
+		t.Fatalf("wrong signal stop: %+v, %v", ev, err)
+	}
+	var gerr *GDBError
+	if _, err := gdb.StopSiginfo(ctx, ev); !errors.As(err, &gerr) {
+		t.Errorf("reading the siginfo should fail: %v", err)
+	}
+	// the address is only read for faults
+	ev, err = gdb.ContinueAndWait(ctx)
+	if err != nil || ev.SignalName != "SIGUSR1" {
+		t.Fatalf("wrong signal stop: %+v, %v", ev, err)
+	}
+	if si, err := gdb.StopSiginfo(ctx, ev); err != nil || *si != (Siginfo{10, 0, 0, 0}) {
+		t.Errorf("wrong siginfo: %+v, %v", si, err)
+	}
+	if si, err := gdb.StopSiginfo(ctx, &GDBEvent{Type: Async_stopped, StopReason: Async_stopped_end_stepping_range}); si != nil || err != nil {
+		t.Errorf("only signals have a siginfo: %+v, %v", si, err)
+	}
+}
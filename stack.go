@@ -1,31 +1,31 @@
 package gdbmi
 
 import (
+	"context"
 	"fmt"
-	"strconv"
 )
 
 type StackListType int
 
 type StackFrame struct {
-	Level    int    `json:"level"`
-	Function string `json:"function"`
-	Address  string `json:"address"`
-	File     string `json:"file"`
-	Line     int    `json:"line"`
-	From     string `json:"from"`
-	Fullname string `json:"fullname"`
+	Level    int    `json:"level" mi:"level"`
+	Function string `json:"function" mi:"func"`
+	Address  string `json:"address" mi:"addr"`
+	File     string `json:"file" mi:"file"`
+	Line     int    `json:"line" mi:"line"`
+	From     string `json:"from" mi:"from"`
+	Fullname string `json:"fullname" mi:"fullname"`
 }
 
 type FrameArgument struct {
-	Name  string `json:"name"`
-	Type  string `json:"type"`
-	Value string `json:"value"`
+	Name  string `json:"name" mi:"name"`
+	Type  string `json:"type" mi:"type"`
+	Value string `json:"value" mi:"value"`
 }
 
 type StackFrameArguments struct {
-	Level     int             `json:"level"`
-	Arguments []FrameArgument `json:"arguments"`
+	Level     int             `json:"level" mi:"level"`
+	Arguments []FrameArgument `json:"arguments" mi:"args"`
 }
 
 const (
@@ -34,94 +34,79 @@ const (
 	ListType_simple_values
 )
 
-func stackFrameInfo(sinfo gdbStruct) (*StackFrame, error) {
+func stackFrameInfo(sinfo Value) (*StackFrame, error) {
 	var result StackFrame
-
-	fmt.Sscanf(mapValueAsString(sinfo, "line", "0"), "%d", &result.Line)
-	fmt.Sscanf(mapValueAsString(sinfo, "level", "0"), "%d", &result.Level)
-	result.Function = mapValueAsString(sinfo, "func", "")
-	result.Address = mapValueAsString(sinfo, "addr", "")
-	result.File = mapValueAsString(sinfo, "file", "")
-	result.From = mapValueAsString(sinfo, "from", "")
-	result.Fullname = mapValueAsString(sinfo, "fullname", "")
-
+	if err := Unmarshal(sinfo, &result); err != nil {
+		return nil, err
+	}
 	return &result, nil
 }
 
 func parseStackFrameInfo(info string) (*StackFrame, error) {
-	return stackFrameInfo(parseStructure(info))
+	sinfo, err := parseStructure(info)
+	if err != nil {
+		return nil, err
+	}
+	return stackFrameInfo(sinfo)
 }
 
-func parseStackFrameArray(info string) (*[]StackFrame, error) {
+func stackFrameArray(frames Value) (*[]StackFrame, error) {
 	var result []StackFrame
-	args := parseStructureArray(info)
-	for _, arg := range args {
-		sf := arg.(gdbStruct)
-		framemap := sf["frame"]
-		frame := framemap.(gdbStruct)
-		sfi, err := stackFrameInfo(frame)
-		if err == nil {
-			result = append(result, *sfi)
-		}
+	if err := Unmarshal(frames, &result); err != nil {
+		return nil, err
 	}
 	return &result, nil
 }
 
-func frameArguments(args []interface{}) []FrameArgument {
+func frameArguments(args Value) (*[]FrameArgument, error) {
 	var result []FrameArgument
-	for _, sa := range args {
-		fa := new(FrameArgument)
-		samap := sa.(gdbStruct)
-		fa.Name = mapValueAsString(samap, "name", "")
-		fa.Type = mapValueAsString(samap, "type", "")
-		fa.Value = mapValueAsString(samap, "value", "")
-		result = append(result, *fa)
+	if err := Unmarshal(args, &result); err != nil {
+		return nil, err
 	}
-	return result
-}
-
-func parseFrameArguments(info string) (*[]FrameArgument, error) {
-	result := frameArguments(parseStructureArray(info))
 	return &result, nil
 }
 
-func parseStackFrameArguments(info string) (*[]StackFrameArguments, error) {
+func stackFrameArguments(args Value) (*[]StackFrameArguments, error) {
 	var result []StackFrameArguments
-	args := parseStructureArray(info)
-	for _, arg := range args {
-		sf := new(StackFrameArguments)
-		sfa := arg.(gdbStruct)
-		framemap := sfa["frame"]
-		frame := framemap.(gdbStruct)
-		fmt.Sscanf(mapValueAsString(frame, "level", "0"), "%d", &sf.Level)
-		sf.Arguments = frameArguments(frame["args"].([]interface{}))
-		result = append(result, *sf)
+	if err := Unmarshal(args, &result); err != nil {
+		return nil, err
 	}
 	return &result, nil
 }
 
 func (gdb *GDB) Stack_list_variables(listtype StackListType) (*[]FrameArgument, error) {
-	c := newCommand("stack-list-variables")
+	return gdb.Stack_list_variablesContext(context.Background(), listtype)
+}
+
+func (gdb *GDB) Stack_list_variablesContext(ctx context.Context, listtype StackListType) (*[]FrameArgument, error) {
+	c := newCommandContext(ctx, "stack-list-variables")
 	c.add_param(fmt.Sprintf("%d", int(listtype)))
 	res, err := gdb.send(c)
 	if err != nil {
 		return nil, err
 	}
-	data := cutoff(res.Results, "variables=", false)
-	return parseFrameArguments(data)
+	return frameArguments(res.Values.Get("variables"))
 }
+
 func (gdb *GDB) Stack_info_frame() (*StackFrame, error) {
-	c := newCommand("stack-info-frame")
+	return gdb.Stack_info_frameContext(context.Background())
+}
+
+func (gdb *GDB) Stack_info_frameContext(ctx context.Context) (*StackFrame, error) {
+	c := newCommandContext(ctx, "stack-info-frame")
 	res, err := gdb.send(c)
 	if err == nil {
-		finfo := cutoff(res.Results, "frame=", false)
-		return parseStackFrameInfo(finfo)
+		return stackFrameInfo(res.Values.Get("frame"))
 	}
 	return nil, err
 }
 
 func (gdb *GDB) Stack_info_depth(maxdepth *int) (int, error) {
-	c := newCommand("stack-info-depth")
+	return gdb.Stack_info_depthContext(context.Background(), maxdepth)
+}
+
+func (gdb *GDB) Stack_info_depthContext(ctx context.Context, maxdepth *int) (int, error) {
+	c := newCommandContext(ctx, "stack-info-depth")
 	if maxdepth != nil {
 		c.add_param(fmt.Sprintf("%d", *maxdepth))
 	}
@@ -130,15 +115,23 @@ func (gdb *GDB) Stack_info_depth(maxdepth *int) (int, error) {
 		return 0, err
 	}
 
-	return strconv.Atoi(cutoff(res.Results, "depth=", true))
+	return res.Values.Get("depth").Int()
 }
 
 func (gdb *GDB) Stack_list_allframes() (*[]StackFrame, error) {
-	return gdb.Stack_list_frames(false, nil, nil)
+	return gdb.Stack_list_allframesContext(context.Background())
+}
+
+func (gdb *GDB) Stack_list_allframesContext(ctx context.Context) (*[]StackFrame, error) {
+	return gdb.Stack_list_framesContext(ctx, false, nil, nil)
 }
 
 func (gdb *GDB) Stack_list_frames(noframefilter bool, from, to *int) (*[]StackFrame, error) {
-	c := newCommand("stack-list-frames").
+	return gdb.Stack_list_framesContext(context.Background(), noframefilter, from, to)
+}
+
+func (gdb *GDB) Stack_list_framesContext(ctx context.Context, noframefilter bool, from, to *int) (*[]StackFrame, error) {
+	c := newCommandContext(ctx, "stack-list-frames").
 		add_option_when(noframefilter, "--no-frame-filters").
 		add_existing_int(from).
 		add_existing_int(to)
@@ -146,12 +139,15 @@ func (gdb *GDB) Stack_list_frames(noframefilter bool, from, to *int) (*[]StackFr
 	if err != nil {
 		return nil, err
 	}
-	data := cutoff(res.Results, "stack=", false)
-	return parseStackFrameArray(data)
+	return stackFrameArray(res.Values.Get("stack"))
 }
 
 func (gdb *GDB) Stack_list_arguments(lsttype StackListType, lowframe *int, highframe *int) (*[]StackFrameArguments, error) {
-	c := newCommand("stack-list-arguments").add_param(fmt.Sprintf("%d", int(lsttype)))
+	return gdb.Stack_list_argumentsContext(context.Background(), lsttype, lowframe, highframe)
+}
+
+func (gdb *GDB) Stack_list_argumentsContext(ctx context.Context, lsttype StackListType, lowframe *int, highframe *int) (*[]StackFrameArguments, error) {
+	c := newCommandContext(ctx, "stack-list-arguments").add_param(fmt.Sprintf("%d", int(lsttype)))
 	if lowframe != nil {
 		c.add_param(fmt.Sprintf("%d", *lowframe))
 	}
@@ -162,6 +158,5 @@ func (gdb *GDB) Stack_list_arguments(lsttype StackListType, lowframe *int, highf
 	if err != nil {
 		return nil, err
 	}
-	data := cutoff(res.Results, "stack-args=", false)
-	return parseStackFrameArguments(data)
+	return stackFrameArguments(res.Values.Get("stack-args"))
 }
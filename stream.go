@@ -0,0 +1,96 @@
+package gdbmi
+
+import (
+	"sync"
+)
+
+type StreamType int
+
+const (
+	Stream_console StreamType = iota
+	Stream_log
+	Stream_target
+)
+
+// A StreamRecord is a line of the console (~), log (&) or target (@) output
+// of GDB. Console output is what GDB prints for CLI commands, log output
+// contains warnings and messages like "No symbol table is loaded".
+type StreamRecord struct {
+	Type StreamType `json:"type"`
+	Text string     `json:"text"`
+}
+
+// A StreamSubscription receives the stream output of GDB on channel C. If the
+// buffer of the channel is full, new records are dropped. C is closed after
+// unsubscribing or when the debugger exits.
+type StreamSubscription struct {
+	C <-chan StreamRecord
+	c chan StreamRecord
+}
+
+type streamSubscribers struct {
+	sync.Mutex
+	subscribers map[*StreamSubscription]bool
+	closed      bool
+}
+
+func (st StreamType) String() string {
+	switch st {
+	case Stream_console:
+		return "console"
+	case Stream_log:
+		return "log"
+	case Stream_target:
+		return "target"
+	}
+	return ""
+}
+
+// SubscribeStream returns a subscription for the stream output of GDB with a
+// buffer for the given number of records.
+func (gdb *GDB) SubscribeStream(buffer int) *StreamSubscription {
+	c := make(chan StreamRecord, buffer)
+	s := &StreamSubscription{C: c, c: c}
+	gdb.streams.Lock()
+	defer gdb.streams.Unlock()
+	if gdb.streams.closed {
+		close(c)
+		return s
+	}
+	if gdb.streams.subscribers == nil {
+		gdb.streams.subscribers = make(map[*StreamSubscription]bool)
+	}
+	gdb.streams.subscribers[s] = true
+	return s
+}
+
+// UnsubscribeStream ends the subscription and closes its channel.
+func (gdb *GDB) UnsubscribeStream(s *StreamSubscription) {
+	gdb.streams.Lock()
+	defer gdb.streams.Unlock()
+	if gdb.streams.subscribers[s] {
+		delete(gdb.streams.subscribers, s)
+		close(s.c)
+	}
+}
+
+func (st *streamSubscribers) publish(rec StreamRecord) {
+	st.Lock()
+	defer st.Unlock()
+	for s := range st.subscribers {
+		select {
+		case s.c <- rec:
+		default:
+		}
+	}
+}
+
+func (st *streamSubscribers) close() {
+	st.Lock()
+	defer st.Unlock()
+	for s := range st.subscribers {
+		close(s.c)
+	}
+	st.subscribers = nil
+	st.closed = true
+}
@@ -0,0 +1,42 @@
+package gdbmi
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStreams(t *testing.T) {
+	gdb := fakeSession(t, expect(`-interpreter-exec console "print 1"`,
+		`&"No symbol table is loaded.  Use the \"file\" command.\n"`,
+		`~"$1 = 1\n"`,
+		`@"target says hello"`,
+		`1^done`,
+	))
+	sub := gdb.SubscribeStream(10)
+	other := gdb.SubscribeStream(10)
+	gdb.UnsubscribeStream(other)
+	res, err := gdb.Exec(context.Background(), "interpreter-exec", nil, "console", "print 1")
+	if err != nil {
+		t.Fatalf("command failed: %s", err)
+	}
+	if len(res.Log) != 1 || res.Log[0] != "No symbol table is loaded.  Use the \"file\" command.\n" || len(res.Console) != 1 {
+		t.Errorf("wrong stream output in result: %+v", res)
+	}
+	expected := []StreamRecord{
+		{Stream_log, "No symbol table is loaded.  Use the \"file\" command.\n"},
+		{Stream_console, "$1 = 1\n"},
+		{Stream_target, "target says hello"},
+	}
+	for _, e := range expected {
+		if rec := <-sub.C; rec != e {
+			t.Errorf("wrong stream record %+v, expected %+v", rec, e)
+		}
+	}
+	if _, ok := <-other.C; ok {
+		t.Errorf("channel of an unsubscribed stream should be closed")
+	}
+	gdb.Close()
+	if _, ok := <-sub.C; ok {
+		t.Errorf("channel should be closed when the debugger exits")
+	}
+}
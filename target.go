@@ -0,0 +1,150 @@
+package gdbmi
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// StartCore starts GDB with the executable and loads the core file of a
+// crashed process of this executable. The stack and thread commands work on
+// the dumped state; running or stepping fails with ErrCoreTarget.
+func (gdb *GDB) StartCore(executable string, corefile string, env ...string) error {
+	if err := gdb.Start(executable, env...); err != nil {
+		return err
+	}
+	if _, err := gdb.Target_core(corefile); err != nil {
+		gdb.Close()
+		return err
+	}
+	return nil
+}
+
+// Target_core loads a core file as the target. The core file is the target
+// until GDB detaches from it, disconnects or loads another program.
+func (gdb *GDB) Target_core(corefile string) (*GDBResult, error) {
+	return gdb.Target_coreContext(context.Background(), corefile)
+}
+
+func (gdb *GDB) Target_coreContext(ctx context.Context, corefile string) (*GDBResult, error) {
+	c := newCommandContext(ctx, "target-select").add_param("core")
+	c.add_quoted_param(corefile)
+	res, err := gdb.send(c)
+	if err == nil {
+		gdb.setCore(true)
+	}
+	return res, err
+}
+
+func (gdb *GDB) setCore(core bool) {
+	var v int32
+	if core {
+		v = 1
+	}
+	atomic.StoreInt32(&gdb.core, v)
+}
+
+func (gdb *GDB) isCore() bool {
+	return atomic.LoadInt32(&gdb.core) == 1
+}
+
+// Target_attach attaches to the running process with the given pid. GDB stops
+// the process and reports it with a thread-group-started and a stopped event
+// without a reason. If GDB may not trace the process, a *PermissionError is
+// returned.
+func (gdb *GDB) Target_attach(pid int) (*GDBResult, error) {
+	return gdb.Target_attachContext(context.Background(), pid)
+}
+
+func (gdb *GDB) Target_attachContext(ctx context.Context, pid int) (*GDBResult, error) {
+	c := newCommandContext(ctx, "target-attach").add_param(strconv.Itoa(pid))
+	res, err := gdb.send(c)
+	var gerr *GDBError
+	if errors.As(err, &gerr) && strings.Contains(gerr.Message, "Operation not permitted") {
+		return nil, &PermissionError{Pid: pid, Err: gerr}
+	}
+	if err == nil {
+		gdb.setCore(false)
+	}
+	return res, err
+}
+
+// Target_detach detaches from the process of the current inferior or, if
+// given, from the process with the pid or the thread group (like "i1").
+// The process continues to run.
+func (gdb *GDB) Target_detach(target string) (*GDBResult, error) {
+	return gdb.Target_detachContext(context.Background(), target)
+}
+
+func (gdb *GDB) Target_detachContext(ctx context.Context, target string) (*GDBResult, error) {
+	c := newCommandContext(ctx, "target-detach")
+	if len(target) > 0 {
+		c.add_param(target)
+	}
+	res, err := gdb.send(c)
+	if err == nil {
+		gdb.setCore(false)
+	}
+	return res, err
+}
+
+// Target_remote connects to a gdbserver. The address is "host:port" for TCP,
+// a serial device or "| command" to start gdbserver and talk to it over a pipe
+// (e.g. "| gdbserver - ./prog"). GDB answers with a connected result.
+func (gdb *GDB) Target_remote(address string) (*GDBResult, error) {
+	return gdb.Target_remoteContext(context.Background(), address)
+}
+
+func (gdb *GDB) Target_remoteContext(ctx context.Context, address string) (*GDBResult, error) {
+	return gdb.target_select(ctx, "remote", address)
+}
+
+// Target_extended_remote connects to a gdbserver started with --multi. In
+// extended mode the program can be run (see Set_remote_exec_file) or GDB
+// can attach to processes of the remote system with Target_attach. After
+// disconnecting the gdbserver keeps running.
+func (gdb *GDB) Target_extended_remote(address string) (*GDBResult, error) {
+	return gdb.Target_extended_remoteContext(context.Background(), address)
+}
+
+func (gdb *GDB) Target_extended_remoteContext(ctx context.Context, address string) (*GDBResult, error) {
+	return gdb.target_select(ctx, "extended-remote", address)
+}
+
+func (gdb *GDB) target_select(ctx context.Context, target string, address string) (*GDBResult, error) {
+	c := newCommandContext(ctx, "target-select").add_param(target)
+	c.add_quoted_param(address)
+	res, err := gdb.send(c)
+	if err == nil {
+		gdb.setCore(false)
+	}
+	return res, err
+}
+
+// Set_remote_exec_file sets the program on the remote system which is started
+// by Exec_run in extended-remote mode. GDB takes the path as it is, it must
+// not be quoted.
+func (gdb *GDB) Set_remote_exec_file(path string) (*GDBResult, error) {
+	return gdb.Set_remote_exec_fileContext(context.Background(), path)
+}
+
+func (gdb *GDB) Set_remote_exec_fileContext(ctx context.Context, path string) (*GDBResult, error) {
+	c := newCommandContext(ctx, "gdb-set").add_param("remote").add_param("exec-file")
+	return gdb.send(c.add_param(path))
+}
+
+// Target_disconnect disconnects from the remote target. The remote target
+// keeps the process.
+func (gdb *GDB) Target_disconnect() (*GDBResult, error) {
+	return gdb.Target_disconnectContext(context.Background())
+}
+
+func (gdb *GDB) Target_disconnectContext(ctx context.Context) (*GDBResult, error) {
+	res, err := gdb.send(newCommandContext(ctx, "target-disconnect"))
+	if err == nil {
+		gdb.setCore(false)
+	}
+	return res, err
+}
@@ -0,0 +1,213 @@
+package gdbmi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTargetAttach(t *testing.T) {
+	gdb := fakeSession(t,
+		expect("-target-attach 4711",
+			`=thread-group-started,id="i1",pid="4711"`,
+			`=thread-created,id="1",group-id="i1"`,
+			`1^done`,
+			`*stopped,frame={addr="0x00007f0d5a4e1e0a",func="__nanosleep",args=[]},thread-id="1",stopped-threads="all",core="0"`,
+		),
+		expect("-target-attach 1", `1^error,msg="ptrace: Operation not permitted."`),
+		expect("-target-detach i1", `1^done`),
+	)
+	events := gdb.Subscribe(EventFilter{}, 10, Backpressure_block)
+	if _, err := gdb.Target_attach(4711); err != nil {
+		t.Fatalf("attach failed: %s", err)
+	}
+	if ev := <-events.C; ev.Type != Async_thread_group_started || ev.Pid != 4711 || ev.ThreadGroupid != "i1" {
+		t.Errorf("wrong event: %+v", ev)
+	}
+	<-events.C
+	if ev := <-events.C; ev.Type != Async_stopped || ev.StopReason != Async_stopped_no_reason || ev.CurrentStackFrame.Function != "__nanosleep" {
+		t.Errorf("wrong stopped event: %+v", ev)
+	}
+	for _, r := range []GDBStopReason{Async_stopped_no_reason, Async_stopped_no_history} {
+		if sr, ok := StopReasonWithName(r.String()); !ok || sr != r {
+			t.Errorf("stop reason %d has no name: '%s'", r, r)
+		}
+	}
+	_, err := gdb.Target_attach(1)
+	var perr *PermissionError
+	if !errors.As(err, &perr) || perr.Pid != 1 || !errors.Is(err, os.ErrPermission) {
+		t.Errorf("attaching should fail with a permission error: %v", err)
+	}
+	if _, err := gdb.Target_detach("i1"); err != nil {
+		t.Errorf("detach failed: %s", err)
+	}
+}
+
+func TestTargetRemote(t *testing.T) {
+	gdb := fakeSession(t,
+		expect("-target-select remote localhost:2345", `1^connected`),
+		expect(`-target-select extended-remote "| gdbserver --multi -"`, `1^connected`),
+		expect(`-gdb-set remote exec-file /opt/my "server"`, `1^done`),
+	)
+	for _, connect := range []func() (*GDBResult, error){
+		func() (*GDBResult, error) { return gdb.Target_remote("localhost:2345") },
+		func() (*GDBResult, error) {
+			return gdb.Target_extended_remote("| gdbserver --multi -")
+		},
+	} {
+		res, err := connect()
+		if err != nil {
+			t.Fatalf("connect failed: %s", err)
+		}
+		if res.Type != Result_connected {
+			t.Errorf("wrong result type: %+v", res)
+		}
+	}
+	if _, err := gdb.Set_remote_exec_file(`/opt/my "server"`); err != nil {
+		t.Errorf("set remote exec-file failed: %s", err)
+	}
+}
+
+// gdbserverAddress starts gdbserver in multi mode on a free port and waits
+// until it accepts connections
+func gdbserverAddress(t *testing.T) string {
+	if _, err := exec.LookPath("gdbserver"); err != nil {
+		t.Skip("gdbserver not found")
+	}
+	if _, err := exec.LookPath("gdb"); err != nil {
+		t.Skip("gdb not found")
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("no free port: %s", err)
+	}
+	address := l.Addr().String()
+	l.Close()
+	server := exec.Command("gdbserver", "--multi", address)
+	if err := server.Start(); err != nil {
+		t.Fatalf("cannot start gdbserver: %s", err)
+	}
+	t.Cleanup(func() {
+		server.Process.Kill()
+		server.Wait()
+	})
+	for i := 0; i < 50; i++ {
+		if c, err := net.Dial("tcp", address); err == nil {
+			c.Close()
+			return address
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("gdbserver does not accept connections on %s", address)
+	return ""
+}
+
+func TestGdbserver(t *testing.T) {
+	address := gdbserverAddress(t)
+	// the remote program has a path which would need quotes
+	program := filepath.Join(t.TempDir(), "my true")
+	data, err := os.ReadFile("/bin/true")
+	if err != nil {
+		t.Fatalf("cannot read the program: %s", err)
+	}
+	if err := os.WriteFile(program, data, 0755); err != nil {
+		t.Fatalf("cannot copy the program: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for _, mode := range []string{"extended-remote", "remote"} {
+		gdb := NewGDB("gdb")
+		if err := gdb.Start(""); err != nil {
+			t.Fatalf("cannot start gdb: %s", err)
+		}
+		exited := gdb.Subscribe(EventFilter{
+			Types:       []GDBAsyncType{Async_stopped},
+			StopReasons: []GDBStopReason{Async_stopped_exited_normally, Async_stopped_exited},
+		}, 1, Backpressure_drop_newest)
+		if mode == "extended-remote" {
+			if _, err = gdb.Target_extended_remoteContext(ctx, address); err != nil {
+				t.Fatalf("cannot connect to %s: %s", address, err)
+			}
+			if _, err = gdb.Set_remote_exec_fileContext(ctx, program); err != nil {
+				t.Fatalf("cannot set remote program: %s", err)
+			}
+			_, err = gdb.Exec_runContext(ctx, false, false, "")
+		} else {
+			if _, err = gdb.Target_remoteContext(ctx, "| gdbserver - /bin/true"); err != nil {
+				t.Fatalf("cannot connect over a pipe: %s", err)
+			}
+			_, err = gdb.Exec_continueContext(ctx, false, false, "")
+		}
+		if err != nil {
+			t.Fatalf("%s: cannot run the program: %s", mode, err)
+		}
+		select {
+		case <-exited.C:
+		case <-ctx.Done():
+			t.Fatalf("%s: program did not exit", mode)
+		}
+		if mode == "extended-remote" {
+			if _, err = gdb.Target_disconnectContext(ctx); err != nil {
+				t.Errorf("cannot disconnect: %s", err)
+			}
+		}
+		gdb.Gdb_exit()
+		gdb.Close()
+	}
+}
+
+func TestTargetCore(t *testing.T) {
+	gdb := fakeSession(t,
+		expect(`-target-select core "/tmp/core dumps/core.4711"`,
+			`1^connected,frame={level="0",addr="0x000000000040052d",func="main",file="crash.c",fullname="/src/crash.c",line="5"}`),
+		expect("-thread-info",
+			`1^done,threads=[{id="2",target-id="LWP 4712",name="worker",frame={level="0",addr="0x00007f0d5a4e1e0a",func="__nanosleep",args=[]},state="stopped",core="1"},{id="1",target-id="LWP 4711",frame={level="0",addr="0x000000000040052d",func="main",args=[],file="crash.c",line="5"},state="stopped"}],current-thread-id="1"`),
+		expect("-thread-select 2",
+			`1^done,new-thread-id="2",frame={level="0",addr="0x00007f0d5a4e1e0a",func="__nanosleep",args=[]}`),
+		expect("-target-detach", `1^done`),
+		expect("-exec-next", `1^error,msg="The program is not being run."`),
+		expect(`-target-select core "/tmp/core dumps/core.4711"`, `1^connected`),
+		expect("-file-exec-and-symbols /tmp/crash", `1^done`),
+		expect("-exec-run", `1^running`),
+	)
+	if _, err := gdb.Target_core("/tmp/core dumps/core.4711"); err != nil {
+		t.Fatalf("cannot load core: %s", err)
+	}
+	info, err := gdb.Thread_info("")
+	if err != nil {
+		t.Fatalf("thread info failed: %s", err)
+	}
+	if len(info.Threads) != 2 || info.CurrentThreadId != "1" || info.Threads[0].Name != "worker" || info.Threads[1].Frame.Line != 5 {
+		t.Errorf("wrong thread info: %+v", info)
+	}
+	if frame, err := gdb.Thread_select("2"); err != nil || frame.Function != "__nanosleep" {
+		t.Errorf("select failed: %+v, %v", frame, err)
+	}
+	if _, err := gdb.Exec_next(false); err != ErrCoreTarget {
+		t.Errorf("stepping a core should fail: %v", err)
+	}
+	if _, err := gdb.Exec_run(false, false, ""); err != ErrCoreTarget {
+		t.Errorf("running a core should fail: %v", err)
+	}
+	if _, err := gdb.Target_detach(""); err != nil {
+		t.Fatalf("detach failed: %s", err)
+	}
+	var gerr *GDBError
+	if _, err := gdb.Exec_next(false); !errors.As(err, &gerr) {
+		t.Errorf("stepping after the detach should be sent to GDB: %v", err)
+	}
+	if _, err := gdb.Target_core("/tmp/core dumps/core.4711"); err != nil {
+		t.Fatalf("cannot load core: %s", err)
+	}
+	if _, err := gdb.File_exec_and_symbols("/tmp/crash"); err != nil {
+		t.Fatalf("cannot load the program: %s", err)
+	}
+	if _, err := gdb.Exec_run(false, false, ""); err != nil {
+		t.Errorf("running the new program failed: %v", err)
+	}
+}
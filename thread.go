@@ -0,0 +1,181 @@
+package gdbmi
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// A Thread of the debugged program. State is "running" or "stopped", the
+// Frame is only set for stopped threads.
+type Thread struct {
+	Id       string      `json:"id" mi:"id"`
+	TargetId string      `json:"targetId" mi:"target-id"`
+	Name     string      `json:"name" mi:"name"`
+	State    string      `json:"state" mi:"state"`
+	Core     string      `json:"core" mi:"core"`
+	Frame    *StackFrame `json:"frame" mi:"frame"`
+}
+
+type ThreadInfo struct {
+	Threads         []Thread `json:"threads" mi:"threads"`
+	CurrentThreadId string   `json:"currentThreadId" mi:"current-thread-id"`
+}
+
+// Thread_info returns all threads or, if threadid is not empty, the given thread.
+func (gdb *GDB) Thread_info(threadid string) (*ThreadInfo, error) {
+	return gdb.Thread_infoContext(context.Background(), threadid)
+}
+
+func (gdb *GDB) Thread_infoContext(ctx context.Context, threadid string) (*ThreadInfo, error) {
+	c := newCommandContext(ctx, "thread-info")
+	if len(threadid) > 0 {
+		c.add_param(threadid)
+	}
+	res, err := gdb.send(c)
+	if err != nil {
+		return nil, err
+	}
+	var info ThreadInfo
+	if err := Unmarshal(res.Values, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Thread_select makes the given thread the current thread and returns its
+// current frame.
+func (gdb *GDB) Thread_select(threadid string) (*StackFrame, error) {
+	return gdb.Thread_selectContext(context.Background(), threadid)
+}
+
+func (gdb *GDB) Thread_selectContext(ctx context.Context, threadid string) (*StackFrame, error) {
+	c := newCommandContext(ctx, "thread-select").add_param(threadid)
+	res, err := gdb.send(c)
+	if err != nil {
+		return nil, err
+	}
+	return stackFrameInfo(res.Values.Get("frame"))
+}
+
+type ThreadState int
+
+const (
+	Thread_unknown ThreadState = iota
+	Thread_running
+	Thread_stopped
+)
+
+func (ts ThreadState) String() string {
+	switch ts {
+	case Thread_running:
+		return "running"
+	case Thread_stopped:
+		return "stopped"
+	}
+	return "unknown"
+}
+
+// threadStates tracks the execution state of the threads from the running,
+// stopped and thread events. In all-stop mode all threads have the same state,
+// in non-stop mode every thread has its own.
+type threadStates struct {
+	sync.Mutex
+	states map[string]ThreadState
+	groups map[string]string
+}
+
+func (ts *threadStates) update(rt *gdb_async) {
+	ts.Lock()
+	defer ts.Unlock()
+	if ts.states == nil {
+		ts.states = make(map[string]ThreadState)
+		ts.groups = make(map[string]string)
+	}
+	switch rt.class {
+	case "thread-created":
+		id := rt.results.Get("id").String()
+		ts.states[id] = Thread_running
+		ts.groups[id] = rt.results.Get("group-id").String()
+	case "thread-exited":
+		id := rt.results.Get("id").String()
+		delete(ts.states, id)
+		delete(ts.groups, id)
+	case "thread-group-exited":
+		gid := rt.results.Get("id").String()
+		for id, g := range ts.groups {
+			if g == gid {
+				delete(ts.states, id)
+				delete(ts.groups, id)
+			}
+		}
+	case "running":
+		ts.set([]string{rt.results.Get("thread-id").String()}, Thread_running)
+	case "stopped":
+		threads := valueStrings(rt.results.Get("stopped-threads"))
+		if len(threads) == 0 {
+			threads = []string{rt.results.Get("thread-id").String()}
+		}
+		ts.set(threads, Thread_stopped)
+	}
+}
+
+func (ts *threadStates) set(threads []string, state ThreadState) {
+	for _, id := range threads {
+		switch id {
+		case "all":
+			for t := range ts.states {
+				ts.states[t] = state
+			}
+		case "":
+		default:
+			ts.states[id] = state
+		}
+	}
+}
+
+// ThreadState returns the execution state of the thread with the given id.
+func (gdb *GDB) ThreadState(id string) ThreadState {
+	gdb.threads.Lock()
+	defer gdb.threads.Unlock()
+	return gdb.threads.states[id]
+}
+
+// ThreadStates returns the execution states of all known threads.
+func (gdb *GDB) ThreadStates() map[string]ThreadState {
+	gdb.threads.Lock()
+	defer gdb.threads.Unlock()
+	res := make(map[string]ThreadState, len(gdb.threads.states))
+	for id, s := range gdb.threads.states {
+		res[id] = s
+	}
+	return res
+}
+
+// Running reports if any thread of the program is running.
+func (gdb *GDB) Running() bool {
+	gdb.threads.Lock()
+	defer gdb.threads.Unlock()
+	for _, s := range gdb.threads.states {
+		if s == Thread_running {
+			return true
+		}
+	}
+	return false
+}
+
+// ThreadOption selects the thread of an exec command in non-stop mode.
+func ThreadOption(id string) Option {
+	return Option{"--thread", id}
+}
+
+// FrameOption selects the frame of a command, it must be used together with a
+// ThreadOption.
+func FrameOption(level int) Option {
+	return Option{"--frame", strconv.Itoa(level)}
+}
+
+// ThreadGroupOption selects the thread group (inferior) of an exec command.
+func ThreadGroupOption(gid string) Option {
+	return Option{"--thread-group", gid}
+}
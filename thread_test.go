@@ -0,0 +1,51 @@
+package gdbmi
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNonStop(t *testing.T) {
+	gdb := fakeSession(t,
+		expect("-exec-run",
+			`=thread-group-started,id="i1",pid="4711"`,
+			`=thread-created,id="1",group-id="i1"`,
+			`=thread-created,id="2",group-id="i1"`,
+			`=thread-created,id="3",group-id="i1"`,
+			`*running,thread-id="all"`,
+			`1^running`),
+		expect("-exec-interrupt --thread 2",
+			`1^done`,
+			`*stopped,reason="signal-received",signal-name="SIGINT",thread-id="2",stopped-threads=["2"]`),
+		expect("-exec-next --thread 3",
+			`1^running`,
+			`*running,thread-id="3"`,
+			`*stopped,reason="breakpoint-hit",bkptno="1",thread-id="1",stopped-threads=["1"]`,
+			`*stopped,reason="end-stepping-range",thread-id="3",stopped-threads=["3"]`),
+	)
+	if _, err := gdb.Exec_run(false, false, ""); err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+	states := gdb.ThreadStates()
+	if len(states) != 3 || states["1"] != Thread_running || !gdb.Running() {
+		t.Errorf("all threads should run: %v", states)
+	}
+	if _, err := gdb.Exec_interrupt(false, "", ThreadOption("2")); err != nil {
+		t.Fatalf("interrupt failed: %s", err)
+	}
+	ev, err := gdb.NextAndWait(context.Background(), ThreadOption("3"))
+	if err != nil {
+		t.Fatalf("next failed: %s", err)
+	}
+	if ev.ThreadId != "3" || ev.StopReason != Async_stopped_end_stepping_range {
+		t.Errorf("wrong stop: %+v", ev)
+	}
+	for id, expected := range map[string]ThreadState{"1": Thread_stopped, "2": Thread_stopped, "3": Thread_stopped, "4": Thread_unknown} {
+		if s := gdb.ThreadState(id); s != expected {
+			t.Errorf("thread %s is %s, expected %s", id, s, expected)
+		}
+	}
+	if gdb.Running() {
+		t.Errorf("no thread should run")
+	}
+}
@@ -0,0 +1,99 @@
+package gdbmi
+
+import (
+	"context"
+)
+
+// A ThreadGroup is an inferior of GDB: a process which is or can be debugged.
+// The id has the form "i1". Pid is 0 and Executable is empty when the inferior
+// does not run or has no program. ExitCode is only set when the program of the
+// inferior exited. Description and User are set for the available processes.
+type ThreadGroup struct {
+	Id          string   `json:"id" mi:"id"`
+	Type        string   `json:"type" mi:"type"`
+	Pid         int      `json:"pid" mi:"pid"`
+	Executable  string   `json:"executable" mi:"executable"`
+	ExitCode    *int     `json:"exitCode"`
+	Description string   `json:"description" mi:"description"`
+	User        string   `json:"user" mi:"user"`
+	Cores       []string `json:"cores" mi:"cores"`
+	Threads     []Thread `json:"threads" mi:"threads"`
+}
+
+// Add_inferior adds a new inferior without a program and returns its id.
+func (gdb *GDB) Add_inferior() (string, error) {
+	return gdb.Add_inferiorContext(context.Background())
+}
+
+func (gdb *GDB) Add_inferiorContext(ctx context.Context) (string, error) {
+	res, err := gdb.send(newCommandContext(ctx, "add-inferior"))
+	if err != nil {
+		return "", err
+	}
+	return res.Values.Get("inferior").String(), nil
+}
+
+// Remove_inferior removes the inferior with the given id. The inferior must
+// not run.
+func (gdb *GDB) Remove_inferior(id string) (*GDBResult, error) {
+	return gdb.Remove_inferiorContext(context.Background(), id)
+}
+
+func (gdb *GDB) Remove_inferiorContext(ctx context.Context, id string) (*GDBResult, error) {
+	return gdb.send(newCommandContext(ctx, "remove-inferior").add_param(id))
+}
+
+// List_thread_groups returns the inferiors or, if given, the thread groups with
+// the ids. With recurse the threads of the groups are listed too. With
+// available the processes of the system which GDB can attach to are listed.
+func (gdb *GDB) List_thread_groups(available bool, recurse bool, groups ...string) ([]ThreadGroup, error) {
+	return gdb.List_thread_groupsContext(context.Background(), available, recurse, groups...)
+}
+
+func (gdb *GDB) List_thread_groupsContext(ctx context.Context, available bool, recurse bool, groups ...string) ([]ThreadGroup, error) {
+	c := newCommandContext(ctx, "list-thread-groups")
+	c.add_option_when(available, "--available")
+	if recurse {
+		c.add_option_value("--recurse", "1")
+	}
+	for _, g := range groups {
+		c.add_param(g)
+	}
+	res, err := gdb.send(c)
+	if err != nil {
+		return nil, err
+	}
+	var tg struct {
+		Groups  []ThreadGroup `mi:"groups"`
+		Threads []Thread      `mi:"threads"`
+	}
+	if err := Unmarshal(res.Values, &tg); err != nil {
+		return nil, err
+	}
+	for i := range tg.Groups {
+		if code, ok := exitCode(res.Values.Get("groups").Index(i)); ok {
+			tg.Groups[i].ExitCode = &code
+		}
+	}
+	if len(groups) == 1 && tg.Groups == nil {
+		// the threads of a single group are returned
+		return []ThreadGroup{{Id: groups[0], Threads: tg.Threads}}, nil
+	}
+	return tg.Groups, nil
+}
+
+// File_exec_and_symbols loads the program and its symbols into the current
+// inferior or, with a ThreadGroupOption, into the given inferior.
+func (gdb *GDB) File_exec_and_symbols(path string, opts ...Option) (*GDBResult, error) {
+	return gdb.File_exec_and_symbolsContext(context.Background(), path, opts...)
+}
+
+func (gdb *GDB) File_exec_and_symbolsContext(ctx context.Context, path string, opts ...Option) (*GDBResult, error) {
+	c := newCommandContext(ctx, "file-exec-and-symbols").add_options(opts)
+	res, err := gdb.send(c.add_quoted_param(path))
+	if err == nil {
+		// the program is run instead of the core file
+		gdb.setCore(false)
+	}
+	return res, err
+}
@@ -0,0 +1,53 @@
+package gdbmi
+
+import (
+	"testing"
+)
+
+func TestThreadGroups(t *testing.T) {
+	gdb := fakeSession(t,
+		expect("-add-inferior", `1^done,inferior="i2"`),
+		expect(`-file-exec-and-symbols --thread-group i2 "/opt/my server/server"`, `1^done`),
+		expect("-exec-run --thread-group i2", `=thread-group-exited,id="i1",exit-code="011"`, `=thread-created,id="2",group-id="i2"`, `1^running`),
+		expect("-list-thread-groups",
+			`1^done,groups=[{id="i1",type="process",exit-code="011",executable="/usr/bin/client"},{id="i2",type="process",pid="4712",executable="/opt/my server/server",cores=["1"]}]`),
+		expect("-list-thread-groups i2",
+			`1^done,threads=[{id="2",target-id="process 4712",frame={level="0",addr="0x1",func="main",args=[]},state="stopped"}]`),
+		expect("-remove-inferior i2", `1^done`),
+	)
+	events := gdb.Subscribe(EventFilter{Types: []GDBAsyncType{Async_thread_group_exited, Async_thread_created}}, 2, Backpressure_block)
+	id, err := gdb.Add_inferior()
+	if err != nil || id != "i2" {
+		t.Fatalf("add inferior failed: %s, %s", id, err)
+	}
+	if _, err := gdb.File_exec_and_symbols("/opt/my server/server", ThreadGroupOption(id)); err != nil {
+		t.Fatalf("load failed: %s", err)
+	}
+	if _, err := gdb.Exec_run(false, false, id); err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+	groups, err := gdb.List_thread_groups(false, false)
+	if err != nil {
+		t.Fatalf("list failed: %s", err)
+	}
+	if len(groups) != 2 || groups[0].Pid != 0 || groups[0].ExitCode == nil || *groups[0].ExitCode != 9 ||
+		groups[1].Pid != 4712 || groups[1].ExitCode != nil || groups[1].Cores[0] != "1" || groups[1].Executable != "/opt/my server/server" {
+		t.Errorf("wrong thread groups: %+v", groups)
+	}
+	groups, err = gdb.List_thread_groups(false, false, id)
+	if err != nil {
+		t.Fatalf("list failed: %s", err)
+	}
+	if len(groups) != 1 || len(groups[0].Threads) != 1 || groups[0].Threads[0].Id != "2" {
+		t.Errorf("wrong threads of group: %+v", groups)
+	}
+	if _, err := gdb.Remove_inferior(id); err != nil {
+		t.Fatalf("remove failed: %s", err)
+	}
+	if ev := <-events.C; ev.ThreadGroupid != "i1" || ev.ExitCode != 9 {
+		t.Errorf("wrong exit: %+v", ev)
+	}
+	if ev := <-events.C; ev.Type != Async_thread_created || ev.ThreadId != "2" || ev.ThreadGroupid != "i2" {
+		t.Errorf("wrong thread: %+v", ev)
+	}
+}
@@ -0,0 +1,228 @@
+package gdbmi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+type ValueKind int
+
+const (
+	Value_invalid ValueKind = iota
+	Value_const
+	Value_tuple
+	Value_list
+)
+
+// A Value is a node in the tree of a parsed GDB/MI result: a Const, a Tuple or a List.
+// Accessing something which does not exist never panics but returns an invalid
+// value, so accessors can be chained like
+//
+//	line, err := res.Values.Get("frame").Get("line").Int()
+//
+// and the error is reported at the end of the chain.
+type Value interface {
+	Kind() ValueKind
+	// Get returns the value of the named field of a tuple.
+	Get(name string) Value
+	// Index returns the i'th element of a list or tuple.
+	Index(i int) Value
+	// Len returns the number of elements of a list or tuple.
+	Len() int
+	// String returns the text of a const or the MI notation of a list or tuple.
+	String() string
+	// Int parses a const as a decimal or 0x prefixed hex number.
+	Int() (int, error)
+	// Uint64 parses a const as a number; hex values like addresses need a 0x prefix.
+	Uint64() (uint64, error)
+}
+
+// A Const is a (unescaped) C string value.
+type Const string
+
+// A Result is a named value, the element of a Tuple.
+type Result struct {
+	Name  string
+	Value Value
+}
+
+// A Tuple is an ordered list of results. GDB sometimes emits the same name
+// more than once, so a Tuple is not a map.
+type Tuple []Result
+
+// A List contains values. A list of results like [frame={...},frame={...}]
+// contains a Tuple with one Result for every element.
+type List []Value
+
+type invalid struct{}
+
+func (c Const) Kind() ValueKind       { return Value_const }
+func (c Const) Get(name string) Value { return invalid{} }
+func (c Const) Index(i int) Value     { return invalid{} }
+func (c Const) Len() int              { return 0 }
+func (c Const) String() string        { return string(c) }
+
+func (c Const) Int() (int, error) {
+	n, err := strconv.ParseInt(numberText(string(c)))
+	return int(n), err
+}
+
+func (c Const) Uint64() (uint64, error) {
+	return strconv.ParseUint(numberText(string(c)))
+}
+
+func (t Tuple) Kind() ValueKind { return Value_tuple }
+func (t Tuple) Len() int        { return len(t) }
+
+func (t Tuple) Get(name string) Value {
+	for _, r := range t {
+		if r.Name == name {
+			return r.Value
+		}
+	}
+	return invalid{}
+}
+
+// GetAll returns the values of all results with the given name.
+func (t Tuple) GetAll(name string) []Value {
+	var res []Value
+	for _, r := range t {
+		if r.Name == name {
+			res = append(res, r.Value)
+		}
+	}
+	return res
+}
+
+func (t Tuple) Index(i int) Value {
+	if i < 0 || i >= len(t) {
+		return invalid{}
+	}
+	return t[i].Value
+}
+
+func (t Tuple) String() string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, r := range t {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "%s=%s", r.Name, miString(r.Value))
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
+func (t Tuple) Int() (int, error) {
+	return 0, fmt.Errorf("cannot convert tuple to int")
+}
+
+func (t Tuple) Uint64() (uint64, error) {
+	return 0, fmt.Errorf("cannot convert tuple to uint64")
+}
+
+// MarshalJSON writes the tuple as a JSON object with the fields in order.
+func (t Tuple) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, r := range t {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(r.Name)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(r.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (l List) Kind() ValueKind       { return Value_list }
+func (l List) Get(name string) Value { return invalid{} }
+func (l List) Len() int              { return len(l) }
+
+func (l List) Index(i int) Value {
+	if i < 0 || i >= len(l) {
+		return invalid{}
+	}
+	return l[i]
+}
+
+func (l List) String() string {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, v := range l {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if t, ok := v.(Tuple); ok && len(t) == 1 {
+			// element of a result list
+			fmt.Fprintf(&buf, "%s=%s", t[0].Name, miString(t[0].Value))
+		} else {
+			buf.WriteString(miString(v))
+		}
+	}
+	buf.WriteByte(']')
+	return buf.String()
+}
+
+func (l List) Int() (int, error) {
+	return 0, fmt.Errorf("cannot convert list to int")
+}
+
+func (l List) Uint64() (uint64, error) {
+	return 0, fmt.Errorf("cannot convert list to uint64")
+}
+
+func (i invalid) Kind() ValueKind       { return Value_invalid }
+func (i invalid) Get(name string) Value { return i }
+func (i invalid) Index(n int) Value     { return i }
+func (i invalid) Len() int              { return 0 }
+func (i invalid) String() string        { return "" }
+
+func (i invalid) Int() (int, error) {
+	return 0, fmt.Errorf("value does not exist")
+}
+
+func (i invalid) Uint64() (uint64, error) {
+	return 0, fmt.Errorf("value does not exist")
+}
+
+func (i invalid) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+// miString returns the MI notation of a value, consts are quoted.
+func miString(v Value) string {
+	if c, ok := v.(Const); ok {
+		return strconv.Quote(string(c))
+	}
+	return v.String()
+}
+
+// valueStrings returns a const as a single string and the consts of a list as strings.
+func valueStrings(v Value) []string {
+	var res []string
+	switch val := v.(type) {
+	case Const:
+		res = append(res, string(val))
+	case List:
+		for _, e := range val {
+			if c, ok := e.(Const); ok {
+				res = append(res, string(c))
+			}
+		}
+	}
+	return res
+}
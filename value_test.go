@@ -0,0 +1,35 @@
+package gdbmi
+
+import (
+	"testing"
+)
+
+func TestConstNumbers(t *testing.T) {
+	for _, tc := range []struct {
+		c     Const
+		n     int
+		u     uint64
+		valid bool
+	}{
+		{"14", 14, 14, true},
+		{"010", 10, 10, true},
+		{"0x0000000000400d10", 0x400d10, 0x400d10, true},
+		{"0X1F", 31, 31, true},
+		{"1_0", 0, 0, false},
+		{"0b11", 0, 0, false},
+		{"0o7", 0, 0, false},
+		{"main", 0, 0, false},
+	} {
+		n, err := tc.c.Int()
+		if (err == nil) != tc.valid || n != tc.n {
+			t.Errorf("Int of %q: %d, %v", tc.c, n, err)
+		}
+		u, err := tc.c.Uint64()
+		if (err == nil) != tc.valid || u != tc.u {
+			t.Errorf("Uint64 of %q: %d, %v", tc.c, u, err)
+		}
+	}
+	if u, err := Const("0xffffffffffffffff").Uint64(); err != nil || u != 1<<64-1 {
+		t.Errorf("Uint64 of the largest address: %#x, %v", u, err)
+	}
+}
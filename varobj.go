@@ -0,0 +1,242 @@
+package gdbmi
+
+import (
+	"context"
+	"fmt"
+)
+
+// A VarFormat is the format in which GDB shows the value of a variable object.
+type VarFormat string
+
+const (
+	Format_natural          VarFormat = "natural"
+	Format_hexadecimal      VarFormat = "hexadecimal"
+	Format_zero_hexadecimal VarFormat = "zero-hexadecimal"
+	Format_octal            VarFormat = "octal"
+	Format_binary           VarFormat = "binary"
+	Format_decimal          VarFormat = "decimal"
+)
+
+// A VarObject is a variable object of GDB: an expression whose value and
+// children (fields of structs, elements of arrays) can be listed and which is
+// updated when the program stops. The Children are set by Var_list_children,
+// Changed is set by Apply.
+type VarObject struct {
+	Name        string       `json:"name" mi:"name"`
+	Expression  string       `json:"expression" mi:"exp"`
+	NumChildren int          `json:"numChildren" mi:"numchild"`
+	Value       string       `json:"value" mi:"value"`
+	Type        string       `json:"type" mi:"type"`
+	ThreadId    string       `json:"threadId" mi:"thread-id"`
+	Frozen      bool         `json:"frozen" mi:"frozen"`
+	Dynamic     bool         `json:"dynamic" mi:"dynamic"`
+	DisplayHint string       `json:"displayHint" mi:"displayhint"`
+	HasMore     bool         `json:"hasMore" mi:"has_more"`
+	Children    []*VarObject `json:"children"`
+	InScope     bool         `json:"inScope"`
+	Changed     bool         `json:"changed"`
+}
+
+// A VarChange is an entry of the changelist of Var_update. InScope is "true",
+// "false" (the variable is out of scope) or "invalid" (the variable does not
+// exist any more). NewNumChildren is only set if the number of children changed.
+type VarChange struct {
+	Name           string       `json:"name" mi:"name"`
+	Value          string       `json:"value" mi:"value"`
+	InScope        string       `json:"inScope" mi:"in_scope"`
+	TypeChanged    bool         `json:"typeChanged" mi:"type_changed"`
+	NewType        string       `json:"newType" mi:"new_type"`
+	NewNumChildren *int         `json:"newNumChildren" mi:"new_num_children"`
+	Dynamic        bool         `json:"dynamic" mi:"dynamic"`
+	DisplayHint    string       `json:"displayHint" mi:"displayhint"`
+	HasMore        bool         `json:"hasMore" mi:"has_more"`
+	NewChildren    []*VarObject `json:"newChildren" mi:"new_children"`
+}
+
+// Var_create creates a variable object for the expression. With an empty name
+// GDB chooses the name. The frame is "*" for the current frame, "@" for a
+// floating variable which is evaluated in the current frame on every update,
+// or the address of a frame. With a ThreadOption the variable belongs to the
+// thread.
+func (gdb *GDB) Var_create(name string, frame string, expression string, opts ...Option) (*VarObject, error) {
+	return gdb.Var_createContext(context.Background(), name, frame, expression, opts...)
+}
+
+func (gdb *GDB) Var_createContext(ctx context.Context, name string, frame string, expression string, opts ...Option) (*VarObject, error) {
+	if len(name) == 0 {
+		name = "-"
+	}
+	if len(frame) == 0 {
+		frame = "*"
+	}
+	c := newCommandContext(ctx, "var-create").add_options(opts)
+	c.add_param(name).add_param(frame).add_quoted_param(expression)
+	res, err := gdb.send(c)
+	if err != nil {
+		return nil, err
+	}
+	v := &VarObject{Expression: expression, InScope: true}
+	if err := Unmarshal(res.Values, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Var_delete deletes the variable object and its children or, with
+// childrenOnly, only its children.
+func (gdb *GDB) Var_delete(name string, childrenOnly bool) (*GDBResult, error) {
+	return gdb.Var_deleteContext(context.Background(), name, childrenOnly)
+}
+
+func (gdb *GDB) Var_deleteContext(ctx context.Context, name string, childrenOnly bool) (*GDBResult, error) {
+	c := newCommandContext(ctx, "var-delete").add_option_when(childrenOnly, "-c")
+	return gdb.send(c.add_param(name))
+}
+
+// Var_set_format sets the format of the value and returns the value in the
+// new format.
+func (gdb *GDB) Var_set_format(name string, format VarFormat) (string, error) {
+	return gdb.Var_set_formatContext(context.Background(), name, format)
+}
+
+func (gdb *GDB) Var_set_formatContext(ctx context.Context, name string, format VarFormat) (string, error) {
+	c := newCommandContext(ctx, "var-set-format").add_param(name).add_param(string(format))
+	res, err := gdb.send(c)
+	if err != nil {
+		return "", err
+	}
+	return res.Values.Get("value").String(), nil
+}
+
+// Var_list_children lists the children of the variable object. The values of
+// the children are listed depending on the listtype. With from and to only
+// the children in this range are listed (for paging); hasMore reports if
+// there are more children. Either both or none of from and to must be given.
+func (gdb *GDB) Var_list_children(name string, listtype StackListType, from, to *int) (children []*VarObject, hasMore bool, err error) {
+	return gdb.Var_list_childrenContext(context.Background(), name, listtype, from, to)
+}
+
+func (gdb *GDB) Var_list_childrenContext(ctx context.Context, name string, listtype StackListType, from, to *int) (children []*VarObject, hasMore bool, err error) {
+	if (from == nil) != (to == nil) {
+		return nil, false, fmt.Errorf("gdbmi: Var_list_children needs both from and to or none of them")
+	}
+	c := newCommandContext(ctx, "var-list-children").add_param(fmt.Sprintf("%d", int(listtype))).add_param(name)
+	if from != nil {
+		c.add_existing_int(from).add_existing_int(to)
+	}
+	res, err := gdb.send(c)
+	if err != nil {
+		return nil, false, err
+	}
+	var list struct {
+		Children []*VarObject `mi:"children"`
+		HasMore  bool         `mi:"has_more"`
+	}
+	if err := Unmarshal(res.Values, &list); err != nil {
+		return nil, false, err
+	}
+	for _, v := range list.Children {
+		v.InScope = true
+	}
+	return list.Children, list.HasMore, nil
+}
+
+// Var_evaluate_expression returns the value of the variable object in its
+// format or, if given, in another format.
+func (gdb *GDB) Var_evaluate_expression(name string, format VarFormat) (string, error) {
+	return gdb.Var_evaluate_expressionContext(context.Background(), name, format)
+}
+
+func (gdb *GDB) Var_evaluate_expressionContext(ctx context.Context, name string, format VarFormat) (string, error) {
+	c := newCommandContext(ctx, "var-evaluate-expression")
+	if len(format) > 0 {
+		c.add_option_value("-f", string(format))
+	}
+	res, err := gdb.send(c.add_param(name))
+	if err != nil {
+		return "", err
+	}
+	return res.Values.Get("value").String(), nil
+}
+
+// Var_assign assigns the expression to the variable and returns the new value.
+func (gdb *GDB) Var_assign(name string, expression string) (string, error) {
+	return gdb.Var_assignContext(context.Background(), name, expression)
+}
+
+func (gdb *GDB) Var_assignContext(ctx context.Context, name string, expression string) (string, error) {
+	c := newCommandContext(ctx, "var-assign").add_param(name).add_quoted_param(expression)
+	res, err := gdb.send(c)
+	if err != nil {
+		return "", err
+	}
+	return res.Values.Get("value").String(), nil
+}
+
+// Var_update updates the variable object and its children, or all variable
+// objects if name is "*" or empty, and returns the changes.
+func (gdb *GDB) Var_update(name string, listtype StackListType) ([]VarChange, error) {
+	return gdb.Var_updateContext(context.Background(), name, listtype)
+}
+
+func (gdb *GDB) Var_updateContext(ctx context.Context, name string, listtype StackListType) ([]VarChange, error) {
+	if len(name) == 0 {
+		name = "*"
+	}
+	c := newCommandContext(ctx, "var-update").add_param(fmt.Sprintf("%d", int(listtype)))
+	res, err := gdb.send(c.add_quoted_param(name))
+	if err != nil {
+		return nil, err
+	}
+	var changes []VarChange
+	if err := Unmarshal(res.Values.Get("changelist"), &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Apply applies the changes of Var_update to the tree of the variable object
+// and returns the changed objects. The Changed flag is set for the changed
+// objects and reset for all others. If the type or the number of children of
+// an object changed, its children are removed and must be listed again.
+func (v *VarObject) Apply(changes []VarChange) []*VarObject {
+	byName := make(map[string]*VarChange, len(changes))
+	for i := range changes {
+		byName[changes[i].Name] = &changes[i]
+	}
+	var changed []*VarObject
+	var apply func(o *VarObject)
+	apply = func(o *VarObject) {
+		o.Changed = false
+		ch, ok := byName[o.Name]
+		if ok {
+			o.Changed = true
+			changed = append(changed, o)
+			o.Value = ch.Value
+			o.InScope = ch.InScope == "true"
+			o.HasMore = ch.HasMore
+			if ch.TypeChanged {
+				o.Type = ch.NewType
+				o.Children = nil
+			}
+			if ch.NewNumChildren != nil {
+				o.NumChildren = *ch.NewNumChildren
+				o.Children = nil
+			}
+		}
+		for _, c := range o.Children {
+			apply(c)
+		}
+		if ok {
+			// children of dynamic variables which were added
+			for _, c := range ch.NewChildren {
+				c.InScope = true
+				c.Changed = true
+				o.Children = append(o.Children, c)
+				changed = append(changed, c)
+			}
+		}
+	}
+	apply(v)
+	return changed
+}
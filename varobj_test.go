@@ -0,0 +1,52 @@
+package gdbmi
+
+import (
+	"testing"
+)
+
+func TestVarObjects(t *testing.T) {
+	gdb := fakeSession(t,
+		expect(`-var-create - * p->items`, `1^done,name="var1",numchild="3",value="[3]",type="int [3]",thread-id="1",has_more="0"`),
+		expect(`-var-list-children 1 var1 0 2`, `1^done,numchild="2",children=[child={name="var1.0",exp="0",numchild="0",value="1",type="int",thread-id="1"},child={name="var1.1",exp="1",numchild="0",value="2",type="int",thread-id="1"}],has_more="1"`),
+		expect(`-var-set-format var1.1 hexadecimal`, `1^done,format="hexadecimal",value="0x2"`),
+		expect(`-var-update 1 *`, `1^done,changelist=[{name="var1.1",value="0x2a",in_scope="true",type_changed="false",has_more="0"}]`),
+		expect(`-var-assign var1.0 "x + 1"`, `1^done,value="5"`),
+		expect(`-var-delete -c var1`, `1^done`),
+	)
+	v, err := gdb.Var_create("", "*", "p->items")
+	if err != nil {
+		t.Fatalf("create failed: %s", err)
+	}
+	if v.Name != "var1" || v.NumChildren != 3 || v.Expression != "p->items" {
+		t.Errorf("wrong variable: %+v", v)
+	}
+	from, to := 0, 2
+	if _, _, err := gdb.Var_list_children(v.Name, ListType_all_values, &from, nil); err == nil {
+		t.Errorf("listing children from without to should fail")
+	}
+	children, more, err := gdb.Var_list_children(v.Name, ListType_all_values, &from, &to)
+	if err != nil {
+		t.Fatalf("list children failed: %s", err)
+	}
+	if len(children) != 2 || !more || children[1].Value != "2" || children[1].Expression != "1" {
+		t.Errorf("wrong children: %+v, %v", children, more)
+	}
+	v.Children = children
+	if val, err := gdb.Var_set_format("var1.1", Format_hexadecimal); err != nil || val != "0x2" {
+		t.Errorf("set format failed: %s, %v", val, err)
+	}
+	changes, err := gdb.Var_update("*", ListType_all_values)
+	if err != nil {
+		t.Fatalf("update failed: %s", err)
+	}
+	changed := v.Apply(changes)
+	if len(changed) != 1 || changed[0] != v.Children[1] || v.Children[1].Value != "0x2a" || v.Children[0].Changed || v.Changed {
+		t.Errorf("wrong changes: %+v", changes)
+	}
+	if val, err := gdb.Var_assign("var1.0", "x + 1"); err != nil || val != "5" {
+		t.Errorf("assign failed: %s, %v", val, err)
+	}
+	if _, err := gdb.Var_delete("var1", true); err != nil {
+		t.Errorf("delete failed: %s", err)
+	}
+}
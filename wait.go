@@ -0,0 +1,179 @@
+package gdbmi
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// cleanupTimeout limits the time to wait for commands which clean up after
+// another command. In all-stop mode GDB does not answer while the program is
+// running, but it executes the command when the program stops.
+var cleanupTimeout = time.Second
+
+// cleanupContext returns a context for cleaning up after ctx, which may have
+// ended already. It has the values of ctx and ends after cleanupTimeout.
+func cleanupContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+}
+
+// RunAndWait runs the program and waits until it stops.
+func (gdb *GDB) RunAndWait(ctx context.Context, opts ...Option) (*GDBEvent, error) {
+	return gdb.resumeAndWait(ctx, "exec-run", opts, func() (*GDBResult, error) {
+		return gdb.Exec_runContext(ctx, false, false, "", opts...)
+	})
+}
+
+// ContinueAndWait continues the program and waits until it stops.
+func (gdb *GDB) ContinueAndWait(ctx context.Context, opts ...Option) (*GDBEvent, error) {
+	return gdb.resumeAndWait(ctx, "exec-continue", opts, func() (*GDBResult, error) {
+		return gdb.Exec_continueContext(ctx, false, false, "", opts...)
+	})
+}
+
+// NextAndWait steps over the current line and waits until the program stops.
+func (gdb *GDB) NextAndWait(ctx context.Context, opts ...Option) (*GDBEvent, error) {
+	return gdb.resumeAndWait(ctx, "exec-next", opts, func() (*GDBResult, error) {
+		return gdb.Exec_nextContext(ctx, false, opts...)
+	})
+}
+
+// NextiAndWait steps over one instruction and waits until the program stops.
+func (gdb *GDB) NextiAndWait(ctx context.Context, opts ...Option) (*GDBEvent, error) {
+	return gdb.resumeAndWait(ctx, "exec-next-instruction", opts, func() (*GDBResult, error) {
+		return gdb.Exec_nextiContext(ctx, false, opts...)
+	})
+}
+
+// StepAndWait steps into the current line and waits until the program stops.
+func (gdb *GDB) StepAndWait(ctx context.Context, opts ...Option) (*GDBEvent, error) {
+	return gdb.resumeAndWait(ctx, "exec-step", opts, func() (*GDBResult, error) {
+		return gdb.Exec_stepContext(ctx, false, opts...)
+	})
+}
+
+// StepiAndWait steps one instruction and waits until the program stops.
+func (gdb *GDB) StepiAndWait(ctx context.Context, opts ...Option) (*GDBEvent, error) {
+	return gdb.resumeAndWait(ctx, "exec-step-instruction", opts, func() (*GDBResult, error) {
+		return gdb.Exec_stepiContext(ctx, false, opts...)
+	})
+}
+
+// FinishAndWait runs until the current function returns and waits until the
+// program stops.
+func (gdb *GDB) FinishAndWait(ctx context.Context, opts ...Option) (*GDBEvent, error) {
+	return gdb.resumeAndWait(ctx, "exec-finish", opts, func() (*GDBResult, error) {
+		return gdb.Exec_finishContext(ctx, false, opts...)
+	})
+}
+
+// RunToLocation runs the program until it reaches the location ("run to
+// cursor"). If the program was not started yet, it is started; without a
+// location it stops at the beginning of main. A temporary breakpoint is used,
+// so the program can stop earlier at another breakpoint.
+func (gdb *GDB) RunToLocation(ctx context.Context, location string) (*GDBEvent, error) {
+	threads, err := gdb.Thread_infoContext(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	started := len(threads.Threads) > 0
+	if len(location) == 0 {
+		if started {
+			return nil, fmt.Errorf("gdbmi: the program is already started")
+		}
+		return gdb.resumeAndWait(ctx, "exec-run", nil, func() (*GDBResult, error) {
+			return gdb.Exec_runContext(ctx, false, true, "")
+		})
+	}
+	bp, err := gdb.temporaryBreakpoint(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	// the breakpoint is left when the program stopped somewhere else
+	defer gdb.deleteBreakpoint(ctx, bp.Number)
+	if started {
+		return gdb.ContinueAndWait(ctx)
+	}
+	return gdb.RunAndWait(ctx)
+}
+
+// Until runs the program until it reaches the location or the current frame
+// returns.
+func (gdb *GDB) Until(ctx context.Context, location string, opts ...Option) (*GDBEvent, error) {
+	return gdb.resumeAndWait(ctx, "exec-until", opts, func() (*GDBResult, error) {
+		return gdb.Exec_untilContext(ctx, location, opts...)
+	})
+}
+
+// JumpTo makes the location the next statement ("set next statement"): the
+// program continues at the location and stops there again.
+func (gdb *GDB) JumpTo(ctx context.Context, location string, opts ...Option) (*GDBEvent, error) {
+	bp, err := gdb.temporaryBreakpoint(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	// the breakpoint is left when the program stopped somewhere else
+	defer gdb.deleteBreakpoint(ctx, bp.Number)
+	return gdb.resumeAndWait(ctx, "exec-jump", opts, func() (*GDBResult, error) {
+		return gdb.Exec_jumpContext(ctx, location, opts...)
+	})
+}
+
+func (gdb *GDB) deleteBreakpoint(ctx context.Context, number string) {
+	ctx, cancel := cleanupContext(ctx)
+	defer cancel()
+	gdb.Break_deleteContext(ctx, number)
+}
+
+func (gdb *GDB) temporaryBreakpoint(ctx context.Context, location string) (*Breakpoint, error) {
+	return gdb.Break_insertContext(ctx, location, true, false, false, false, false, nil, nil, nil)
+}
+
+// resumeAndWait resumes the program and waits until it stops again; it is used
+// by all functions which end with AndWait. It returns the stopped event; if
+// the program exited, the StopReason is one of the exited reasons and ExitCode
+// is set. The events are subscribed before the command is sent, so a stop which
+// GDB reports before the result of the command is not lost. With a
+// ThreadOption in non-stop mode, it waits for the stop of this thread and
+// skips the stops of other threads. If the context ends before the program
+// stops, a *TimeoutError is returned and the program keeps running.
+func (gdb *GDB) resumeAndWait(ctx context.Context, command string, opts []Option, resume func() (*GDBResult, error)) (*GDBEvent, error) {
+	thread := ""
+	for _, o := range opts {
+		if option_name(o.Name) == "--thread" {
+			thread = o.Value
+		}
+	}
+	stops := gdb.Subscribe(EventFilter{Types: []GDBAsyncType{Async_stopped}}, 16, Backpressure_drop_oldest)
+	defer gdb.Unsubscribe(stops)
+	if _, err := resume(); err != nil {
+		return nil, err
+	}
+	for {
+		select {
+		case ev, ok := <-stops.C:
+			if !ok {
+				return nil, ErrDebuggerExited
+			}
+			if stoppedThread(&ev, thread) {
+				return &ev, nil
+			}
+		case <-ctx.Done():
+			return nil, &TimeoutError{Command: command, Err: ctx.Err()}
+		}
+	}
+}
+
+// stoppedThread checks if the thread is stopped by the event. All threads match
+// an empty thread.
+func stoppedThread(ev *GDBEvent, thread string) bool {
+	if len(thread) == 0 || ev.ThreadId == thread || len(ev.StoppedThreads) == 0 {
+		return true
+	}
+	for _, t := range ev.StoppedThreads {
+		if t == thread || t == "all" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package gdbmi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResumeAndWait(t *testing.T) {
+	gdb := fakeSession(t,
+		// the stop can be reported before the result
+		expect("-exec-step",
+			`*running,thread-id="all"`,
+			`*stopped,reason="end-stepping-range",frame={addr="0x1",func="main",args=[],file="main.c",line="6"},thread-id="1"`,
+			`1^running`),
+		expect("-exec-continue",
+			`1^running`,
+			`*running,thread-id="all"`,
+			`*stopped,reason="exited",exit-code="011"`),
+		expect("-exec-continue",
+			`1^running`,
+			`*stopped,reason="syscall-return",syscall-number="231",syscall-name="exit_group",thread-id="1"`),
+		// a reason of a newer GDB
+		expect("-exec-continue",
+			`1^running`,
+			`*stopped,reason="new-reason",thread-id="1"`),
+		expect("-exec-next", `1^running`),
+	)
+	ev, err := gdb.StepAndWait(context.Background())
+	if err != nil {
+		t.Fatalf("step failed: %s", err)
+	}
+	if ev.StopReason != Async_stopped_end_stepping_range || ev.CurrentStackFrame.Line != 6 {
+		t.Errorf("wrong stop: %+v", ev)
+	}
+	ev, err = gdb.ContinueAndWait(context.Background())
+	if err != nil {
+		t.Fatalf("continue failed: %s", err)
+	}
+	if ev.StopReason != Async_stopped_exited || ev.ExitCode != 9 {
+		t.Errorf("wrong exit: %+v", ev)
+	}
+	ev, err = gdb.ContinueAndWait(context.Background())
+	if err != nil || ev.StopReason != Async_stopped_syscall_return || ev.StopReasonText != "syscall-return" {
+		t.Errorf("wrong syscall stop: %+v, %v", ev, err)
+	}
+	ev, err = gdb.ContinueAndWait(context.Background())
+	if err != nil || ev.StopReason != Async_stopped_unknown || ev.StopReasonText != "new-reason" {
+		t.Errorf("wrong stop with an unknown reason: %+v, %v", ev, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = gdb.NextAndWait(ctx)
+	var terr *TimeoutError
+	if !errors.As(err, &terr) || !terr.Timeout() {
+		t.Errorf("waiting should time out: %v", err)
+	}
+}
+
+func TestRunToLocation(t *testing.T) {
+	defer func(d time.Duration) { cleanupTimeout = d }(cleanupTimeout)
+	cleanupTimeout = 50 * time.Millisecond
+	gdb := fakeSession(t,
+		expect("-thread-info", `1^done,threads=[]`),
+		expect("-break-insert -t main.c:12",
+			`1^done,bkpt={number="2",type="breakpoint",disp="del",enabled="y",addr="0x1",func="main",file="main.c",line="12"}`),
+		expect("-exec-run",
+			`1^running`,
+			`*stopped,reason="breakpoint-hit",disp="del",bkptno="2",frame={addr="0x1",func="main",args=[],file="main.c",line="12"},thread-id="1"`),
+		expect("-break-delete 2", `1^error,msg="No breakpoint number 2."`),
+		expect("-thread-info", `1^done,threads=[{id="1",target-id="process 4711",state="stopped"}]`),
+		expect("-break-insert -t main.c:20",
+			`1^done,bkpt={number="3",type="breakpoint",disp="del",enabled="y",addr="0x2",func="main",file="main.c",line="20"}`),
+		// the program does not reach the location in time and GDB does
+		// not answer while it is running
+		expect("-exec-continue", `1^running`),
+		expect("-break-delete 3"),
+	)
+	ev, err := gdb.RunToLocation(context.Background(), "main.c:12")
+	if err != nil {
+		t.Fatalf("run to location failed: %s", err)
+	}
+	if ev.StopReason != Async_stopped_breakpoint_hit || ev.CurrentStackFrame.Line != 12 {
+		t.Errorf("wrong stop: %+v", ev)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = gdb.RunToLocation(ctx, "main.c:20")
+	var terr *TimeoutError
+	if !errors.As(err, &terr) {
+		t.Errorf("waiting should time out: %v", err)
+	}
+	if d := time.Since(start); d > 10*cleanupTimeout {
+		t.Errorf("deleting the breakpoint should not block: %s", d)
+	}
+}
+
+func TestJumpTo(t *testing.T) {
+	gdb := fakeSession(t,
+		expect("-break-insert -t main.c:30",
+			`1^done,bkpt={number="4",type="breakpoint",disp="del",enabled="y",addr="0x3",func="main",file="main.c",line="30"}`),
+		expect("-exec-jump --thread 1 main.c:30",
+			`1^running`,
+			`*stopped,reason="breakpoint-hit",disp="del",bkptno="4",frame={addr="0x3",func="main",args=[],file="main.c",line="30"},thread-id="1"`),
+		expect("-break-delete 4", `1^error,msg="No breakpoint number 4."`),
+		expect("-exec-until main.c:40",
+			`1^running`,
+			`*stopped,reason="location-reached",frame={addr="0x4",func="main",args=[],file="main.c",line="40"},thread-id="1"`),
+	)
+	ev, err := gdb.JumpTo(context.Background(), "main.c:30", ThreadOption("1"))
+	if err != nil || ev.CurrentStackFrame.Line != 30 {
+		t.Errorf("jump failed: %+v, %v", ev, err)
+	}
+	ev, err = gdb.Until(context.Background(), "main.c:40")
+	if err != nil || ev.StopReason != Async_stopped_location_reached {
+		t.Errorf("until failed: %+v, %v", ev, err)
+	}
+}